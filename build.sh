@@ -39,6 +39,9 @@ else
   )
 fi
 
+# Cross compiled releases are built without cgo, so every dependency must be pure Go
+export CGO_ENABLED=0
+
 # Loop through each OS/architecture combination
 mkdir -p build
 echo "Building..."
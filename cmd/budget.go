@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+// Budget caps the coins spent, a zero cap is no cap
+type Budget struct {
+	Request float64 `json:"request,omitempty"`
+	Session float64 `json:"session,omitempty"`
+	Day     float64 `json:"day,omitempty"`
+}
+
+// Estimate is what a prompt is expected to cost before it is sent
+type Estimate struct {
+	// Words is the length of the message sent, context and system prompt included
+	Words int
+	// AnswerWords is the longest answer allowed by the prompt's max tokens, zero when it is not set
+	AnswerWords int
+	// Coins is what every priced model charges for Words, and for AnswerWords when set. The budget checks it.
+	Coins float64
+	// UpTo is the worst case, with each model giving the longest answer it may. It is only shown.
+	UpTo float64
+	// Unpriced are the models missing from the catalog, their cost is unknown
+	Unpriced []string
+	// Attachments are charged by the API on top of the estimate
+	Attachments int
+}
+
+// EstimateCost prices the message p would send for text with each of its models, and the answer when
+// the prompt's max tokens limits it. Without a limit only UpTo counts the model's own output limit.
+func EstimateCost(models []Models, p prompt.Prompt, text string, context []prompt.Turn) Estimate {
+	e := Estimate{
+		Words:       len(strings.Fields(p.BuildMessage(text, context))),
+		Attachments: len(p.FileUrls) + len(p.YoutubeUrls),
+	}
+	for _, id := range p.Model {
+		priced := false
+		for _, m := range models {
+			if m.Id == id && m.PricingWords > 0 {
+				price := m.Pricing / float64(m.PricingWords)
+				answer := prompt.OutputWords(answerTokens(p, m))
+				e.UpTo += float64(e.Words+answer) * price
+				if p.MaxToken > 0 {
+					e.AnswerWords = max(e.AnswerWords, answer)
+					e.Coins += float64(e.Words+answer) * price
+				} else {
+					e.Coins += float64(e.Words) * price
+				}
+				priced = true
+				break
+			}
+		}
+		if !priced {
+			e.Unpriced = append(e.Unpriced, id)
+		}
+	}
+	return e
+}
+
+// answerTokens is the most tokens m may answer p with, zero when unknown
+func answerTokens(p prompt.Prompt, m Models) int64 {
+	tokens := int64(p.MaxToken)
+	if tokens == 0 || (m.MaxOutput > 0 && m.MaxOutput < tokens) {
+		tokens = m.MaxOutput
+	}
+	return tokens
+}
+
+// String describes the estimate for the status line,
+// e.g. ≈ 0.02 coins for 350 words before the answer, up to 4.10 coins with the longest answer
+func (e Estimate) String() string {
+	var b strings.Builder
+	b.WriteString("≈ " + strconv.FormatFloat(e.Coins, 'f', 2, 64) + " coins for " + strconv.Itoa(e.Words) + " words")
+	if e.AnswerWords > 0 {
+		b.WriteString(" and an answer of up to " + strconv.Itoa(e.AnswerWords) + " words")
+	} else {
+		b.WriteString(" before the answer")
+	}
+	if e.Attachments > 0 {
+		b.WriteString(" + " + strconv.Itoa(e.Attachments) + " attachments")
+	}
+	if len(e.Unpriced) > 0 {
+		b.WriteString(", unknown price for " + strings.Join(e.Unpriced, ", "))
+	}
+	if e.UpTo > e.Coins {
+		b.WriteString(", up to " + strconv.FormatFloat(e.UpTo, 'f', 2, 64) + " coins with the longest answer")
+	}
+	return b.String()
+}
+
+// BudgetError tells which budget a prompt may exceed
+type BudgetError struct {
+	// Limit is per request, per session or per day
+	Limit    string
+	Budget   float64
+	Spent    float64
+	Estimate Estimate
+}
+
+func (e *BudgetError) Error() string {
+	message := "this prompt may cost " + strconv.FormatFloat(e.Estimate.Coins, 'f', 2, 64) + " coins"
+	if e.Spent > 0 {
+		message += " on top of the " + strconv.FormatFloat(e.Spent, 'f', 2, 64) + " spent"
+	}
+	return message + ", over the " + e.Limit + " budget of " + formatCoins(e.Budget)
+}
+
+// Check returns a *BudgetError when the estimate goes over a cap, given the coins already spent
+// in the session and today
+func (b Budget) Check(e Estimate, session float64, today float64) error {
+	switch {
+	case b.Request > 0 && e.Coins > b.Request:
+		return &BudgetError{Limit: "per request", Budget: b.Request, Estimate: e}
+	case b.Session > 0 && session+e.Coins > b.Session:
+		return &BudgetError{Limit: "per session", Budget: b.Session, Spent: session, Estimate: e}
+	case b.Day > 0 && today+e.Coins > b.Day:
+		return &BudgetError{Limit: "per day", Budget: b.Day, Spent: today, Estimate: e}
+	}
+	return nil
+}
+
+// checkBudget refuses a single prompt that may exceed a budget, unless --over-budget is given.
+// Without the catalog the prompt can't be priced and is sent.
+func (c *ConfigFile) checkBudget(p prompt.Prompt, message string) error {
+	if c.OverBudget || c.Budget == (Budget{}) {
+		return nil
+	}
+	models, err := GetModels(c.Key)
+	if err != nil {
+		return nil
+	}
+	today, err := SpentToday()
+	if err != nil {
+		return err
+	}
+	if err := c.Budget.Check(EstimateCost(models, p, message, nil), 0, today); err != nil {
+		return fmt.Errorf("%w, use --over-budget to send it anyway", err)
+	}
+	return nil
+}
+
+// SpentToday totals the coins of today's answers in the usage ledger
+func SpentToday() (float64, error) {
+	entries, err := ReadUsage()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	var coins float64
+	for _, e := range entries {
+		if !e.Time.Before(midnight) {
+			coins += e.Price.Total
+		}
+	}
+	return coins, nil
+}
+
+// projectSetting lets a project file set s
+func projectSetting(s Setting) Setting {
+	s.Project = true
+	return s
+}
+
+// coinSetting is a number of coins, such as a cap of the budget
+func coinSetting(name string, env string, field func(c *ConfigFile) *float64) Setting {
+	return Setting{
+		Name: name, Env: env,
+		get: func(c *ConfigFile) string {
+			if *field(c) == 0 {
+				return ""
+			}
+			return formatCoins(*field(c))
+		},
+		set: func(c *ConfigFile, v string) error {
+			if v == "" {
+				*field(c) = 0
+				return nil
+			}
+			coins, err := strconv.ParseFloat(v, 64)
+			if err != nil || coins < 0 {
+				return fmt.Errorf("%s must be a positive number of coins, got %q", name, v)
+			}
+			*field(c) = coins
+			return nil
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestEstimateCost(t *testing.T) {
+	models := []Models{
+		{Id: "openai/gpt-4.1", Pricing: 3, PricingWords: 100},
+		{Id: "anthropic/claude-sonnet-4", Pricing: 5, PricingWords: 100},
+	}
+	p := prompt.Prompt{Model: []string{"openai/gpt-4.1", "anthropic/claude-sonnet-4", "other/model"}, FileUrls: []string{"https://example.com/a.pdf"}}
+	e := EstimateCost(models, p, strings.Repeat("word ", 50), nil)
+	if e.Words != 50 || e.Coins != 4 {
+		t.Errorf("Expected 50 words costing 4 coins with both models, got %d words costing %v", e.Words, e.Coins)
+	}
+	if len(e.Unpriced) != 1 || e.Attachments != 1 {
+		t.Errorf("Expected an unpriced model and an attachment, got %+v", e)
+	}
+	if got := e.String(); !strings.HasPrefix(got, "≈ 4.00 coins for 50 words before the answer + 1 attachments") {
+		t.Errorf("Expected the estimate described, got %q", got)
+	}
+}
+
+func TestEstimateCostIncludesAnswer(t *testing.T) {
+	models := []Models{
+		{Id: "openai/gpt-4.1", Pricing: 3, PricingWords: 100, MaxOutput: 1000},
+		{Id: "anthropic/claude-sonnet-4", Pricing: 5, PricingWords: 100, MaxOutput: 200},
+	}
+	text := strings.Repeat("word ", 50)
+
+	// Without max tokens only the prompt is counted, each model may still answer up to its own limit: 750 and 150 words
+	p := prompt.Prompt{Model: []string{"openai/gpt-4.1", "anthropic/claude-sonnet-4"}}
+	e := EstimateCost(models, p, text, nil)
+	if e.AnswerWords != 0 || e.Coins != 1.5+2.5 || e.UpTo != 24+10 {
+		t.Errorf("Expected 4 coins, up to 34 with the longest answers, got %d words costing %v up to %v", e.AnswerWords, e.Coins, e.UpTo)
+	}
+	if got := e.String(); got != "≈ 4.00 coins for 50 words before the answer, up to 34.00 coins with the longest answer" {
+		t.Errorf("Expected the worst case in the description, got %q", got)
+	}
+	if err := (Budget{Request: 10}).Check(e, 0, 0); err != nil {
+		t.Errorf("Expected the per request budget to check the prompt only, got %v", err)
+	}
+
+	// Max tokens shortens the answers, 400 tokens being 300 words
+	p.MaxToken = 400
+	e = EstimateCost(models, p, text, nil)
+	if e.AnswerWords != 300 || e.Coins != 10.5+10 || e.UpTo != e.Coins {
+		t.Errorf("Expected answers of up to 300 words costing 20.5 coins, got %d words costing %v", e.AnswerWords, e.Coins)
+	}
+	if got := e.String(); got != "≈ 20.50 coins for 50 words and an answer of up to 300 words" {
+		t.Errorf("Expected the answer in the description, got %q", got)
+	}
+}
+
+func TestBudgetCheck(t *testing.T) {
+	budget := Budget{Request: 5, Session: 20, Day: 50}
+	for _, test := range []struct {
+		coins, session, today float64
+		limit                 string
+	}{
+		{4, 10, 40, ""},
+		{6, 0, 0, "per request"},
+		{4, 17, 0, "per session"},
+		{4, 0, 47, "per day"},
+	} {
+		err := budget.Check(Estimate{Coins: test.coins}, test.session, test.today)
+		var over *BudgetError
+		switch {
+		case test.limit == "" && err != nil:
+			t.Errorf("Expected %v coins to fit, got %v", test.coins, err)
+		case test.limit != "" && (!errors.As(err, &over) || over.Limit != test.limit):
+			t.Errorf("Expected the %s budget to be exceeded, got %v", test.limit, err)
+		}
+	}
+	if err := (Budget{}).Check(Estimate{Coins: 1000}, 1000, 1000); err != nil {
+		t.Errorf("Expected no cap without a budget, got %v", err)
+	}
+}
+
+func TestSpentToday(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	RecordUsage([]UsageEntry{
+		{Time: time.Now().AddDate(0, 0, -1), Price: prompt.OverallPrice{Total: 10}},
+		{Time: time.Now(), Price: prompt.OverallPrice{Total: 2.5}},
+	})
+	if spent, err := SpentToday(); err != nil || spent != 2.5 {
+		t.Errorf("Expected 2.5 coins today, got %v, %v", spent, err)
+	}
+}
+
+func TestAskOverBudget(t *testing.T) {
+	catalogServer(t)
+	t.Setenv("STRAICO_BUDGET_REQUEST", "0.01")
+
+	stdio := IO{Stdin: strings.NewReader(""), Stdout: &strings.Builder{}, Stderr: &strings.Builder{}}
+	err := runAsk([]string{"-m", "openai/gpt-4.1", "hello", "world"}, stdio)
+	if err == nil || !strings.Contains(err.Error(), "per request budget of 0.01") {
+		t.Errorf("Expected the prompt to be refused, got %v", err)
+	}
+
+	stdio.Stdin = strings.NewReader("")
+	err = runAsk([]string{"-m", "openai/gpt-4.1", "--over-budget", "hello", "world"}, stdio)
+	if err != nil && strings.Contains(err.Error(), "budget") {
+		t.Errorf("Expected --over-budget to send the prompt, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/statefile"
+)
+
+// catalogFile caches the models of each API, so they are only asked for once per CatalogTTL
+const catalogFile = "models.json"
+
+// CatalogTTL is how long the cached models are used without asking the API
+var CatalogTTL = 24 * time.Hour
+
+var catalogClient = http.Client{
+	Timeout: time.Second * 20,
+}
+
+// cachedCatalog is the last models response of an API
+type cachedCatalog struct {
+	Fetched      time.Time      `json:"fetched"`
+	ETag         string         `json:"etag,omitempty"`
+	LastModified string         `json:"last_modified,omitempty"`
+	Models       ModelsResponse `json:"models"`
+}
+
+// FetchModels returns every chat and image model of the API, from the cache while it is fresh.
+// When the API can't be reached, the cache is used however old it is.
+func FetchModels(apiKey string) (ModelsResponse, error) {
+	return fetchModels(apiKey, false)
+}
+
+// RefreshModels asks the API for the models even when the cache is fresh
+func RefreshModels(apiKey string) (ModelsResponse, error) {
+	return fetchModels(apiKey, true)
+}
+
+func fetchModels(apiKey string, refresh bool) (ModelsResponse, error) {
+	path, err := catalogPath()
+	if err != nil {
+		return requestModels(apiKey, nil)
+	}
+	api := modelsApi
+	cached, ok := readCatalogs(path)[api]
+	if ok && !refresh && time.Since(cached.Fetched) < CatalogTTL {
+		return cached.Models, nil
+	}
+
+	var previous *cachedCatalog
+	if ok {
+		previous = &cached
+	}
+	fresh, err := requestCatalog(apiKey, previous)
+	if err != nil {
+		if ok {
+			return cached.Models, nil
+		}
+		return ModelsResponse{}, err
+	}
+	// The cache only saves a request, failing to write it is no reason to fail
+	_ = saveCatalog(path, api, fresh)
+	return fresh.Models, nil
+}
+
+// requestModels asks the API, bypassing the cache
+func requestModels(apiKey string, previous *cachedCatalog) (ModelsResponse, error) {
+	catalog, err := requestCatalog(apiKey, previous)
+	return catalog.Models, err
+}
+
+// requestCatalog asks the API for the models. With a previous response, the API may answer that nothing changed.
+func requestCatalog(apiKey string, previous *cachedCatalog) (cachedCatalog, error) {
+	req, _ := http.NewRequest("GET", modelsApi, nil)
+	req.Header = http.Header{
+		"Authorization": []string{"Bearer " + apiKey},
+		"Accept":        []string{"application/json"},
+	}
+	if previous != nil && previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+	if previous != nil && previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+	resp, err := catalogClient.Do(req)
+	if err != nil {
+		return cachedCatalog{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		unchanged := *previous
+		unchanged.Fetched = time.Now()
+		return unchanged, nil
+	}
+	if resp.StatusCode > 299 || resp.StatusCode < 200 {
+		errorMessage := fmt.Errorf("request failed. Error: %s", resp.Status)
+		return cachedCatalog{}, errorMessage
+	}
+	bodyText, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cachedCatalog{}, fmt.Errorf("unable to read body. Error: %w", err)
+	}
+
+	straicoModels, err := UnmarshalStraicoModels(bodyText)
+	if err != nil {
+		errorMessage := fmt.Errorf("request failed. Error: %w", err)
+		return cachedCatalog{}, errorMessage
+	}
+	return cachedCatalog{
+		Fetched:      time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Models:       straicoModels,
+	}, nil
+}
+
+func catalogPath() (string, error) {
+	var c ConfigFile
+	configDir, err := c.getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, catalogFile), nil
+}
+
+// readCatalogs reads the cached responses by API url, a missing or broken cache is empty
+func readCatalogs(path string) map[string]cachedCatalog {
+	catalogs := make(map[string]cachedCatalog)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return catalogs
+	}
+	if err := json.Unmarshal(data, &catalogs); err != nil {
+		return make(map[string]cachedCatalog)
+	}
+	return catalogs
+}
+
+func saveCatalog(path string, api string, catalog cachedCatalog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	catalogs := readCatalogs(path)
+	catalogs[api] = catalog
+	data, err := json.MarshalIndent(catalogs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return statefile.Write(path, data, 0600)
+}
+
+// CheckModels makes sure the API offers the selected models, suggesting the closest ids for a typo.
+// Nothing is checked when the models can't be had, the request fails later with a clearer error.
+func (c *ConfigFile) CheckModels() error {
+	models, err := GetModels(c.Key)
+	if err != nil || len(models) == 0 {
+		return nil
+	}
+	for _, id := range c.Prompt.Model {
+		if err := unknownModel(models, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unknownModel returns an error when id is not one of models
+func unknownModel(models []Models, id string) error {
+	for _, m := range models {
+		if m.Id == id {
+			return nil
+		}
+	}
+	if suggestions := suggestModels(models, id); len(suggestions) > 0 {
+		return fmt.Errorf("unknown model %q, did you mean %s?", id, strings.Join(suggestions, " or "))
+	}
+	return fmt.Errorf("unknown model %q, see straico-cli models", id)
+}
+
+// suggestModels returns up to 3 model ids close to id, closest first
+func suggestModels(models []Models, id string) []string {
+	type candidate struct {
+		id       string
+		distance int
+	}
+	lower := strings.ToLower(id)
+	var candidates []candidate
+	for _, m := range models {
+		other := strings.ToLower(m.Id)
+		distance := levenshtein(lower, other)
+		// A missing provider prefix, e.g. gpt-4.1 for openai/gpt-4.1, is close too
+		if _, name, found := strings.Cut(other, "/"); found && name == lower {
+			distance = 0
+		}
+		if distance <= max(2, len(lower)/4) {
+			candidates = append(candidates, candidate{m.Id, distance})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.distance - b.distance })
+	suggestions := make([]string, 0, 3)
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, candidates[i].id)
+	}
+	return suggestions
+}
+
+// levenshtein is the number of single character edits turning a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchModelsCache(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	requests, offline := 0, false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if offline {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testCatalog))
+	}))
+	defer server.Close()
+	modelsApi = server.URL + "/v1/models"
+	defer func() { modelsApi = DefaultBaseURL + "/v1/models" }()
+
+	models, err := FetchModels("test-key")
+	if err != nil || len(models.Data.Chat) != 3 {
+		t.Fatalf("Expected 3 chat models, got %d, %v", len(models.Data.Chat), err)
+	}
+	if _, err := FetchModels("test-key"); err != nil || requests != 1 {
+		t.Errorf("Expected the second call to use the cache, got %d requests, %v", requests, err)
+	}
+	info, err := os.Stat(filepath.Join(home, ".config", "straico-cli", catalogFile))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected a private cache file, got %v", err)
+	}
+
+	// Once stale, the ETag lets the API answer that nothing changed
+	CatalogTTL = 0
+	defer func() { CatalogTTL = 24 * time.Hour }()
+	if models, err := FetchModels("test-key"); err != nil || requests != 2 || len(models.Data.Image) != 1 {
+		t.Errorf("Expected the cached models after a 304, got %d requests, %v", requests, err)
+	}
+
+	// The stale cache is better than nothing when the API is down
+	offline = true
+	if models, err := FetchModels("test-key"); err != nil || len(models.Data.Chat) != 3 {
+		t.Errorf("Expected the stale cache while offline, got %v", err)
+	}
+}
+
+func TestSuggestModels(t *testing.T) {
+	models := []Models{{Id: "openai/gpt-4.1"}, {Id: "openai/gpt-4.1-mini"}, {Id: "anthropic/claude-sonnet-4"}}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"openai/gpt-4.1-mni", "openai/gpt-4.1-mini"},
+		{"gpt-4.1", "openai/gpt-4.1"},
+		{"anthropic/claude-sonet-4", "anthropic/claude-sonnet-4"},
+		{"mistral/large", ""},
+	}
+	for _, tt := range tests {
+		got := strings.Join(suggestModels(models, tt.id), ",")
+		if !strings.HasPrefix(got, tt.want) || (tt.want == "" && got != "") {
+			t.Errorf("Expected %q to suggest %q first, got %q", tt.id, tt.want, got)
+		}
+	}
+
+	if err := unknownModel(models, "openai/gpt-4.1"); err != nil {
+		t.Errorf("Expected a known model to pass, got %v", err)
+	}
+	if err := unknownModel(models, "mistral/large"); err == nil || !strings.Contains(err.Error(), "straico-cli models") {
+		t.Errorf("Expected a pointer to the models command, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+)
+
+// IO is where a command reads and writes, so it can run outside a terminal and in tests
+type IO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Command is a straico-cli subcommand. Run gets the arguments after the command name.
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string, stdio IO) error
+}
+
+// Execute runs the command named by the first argument. Without one, the first command runs with all arguments,
+// so "straico-cli -m model" still starts a chat.
+func Execute(commands []Command, args []string, stdio IO) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "--help":
+			return help(commands, args[1:], stdio)
+		}
+		for _, c := range commands {
+			if c.Name == args[0] {
+				return ignoreHelp(c.Run(args[1:], stdio))
+			}
+		}
+		if !strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("unknown command %q, see straico-cli help", args[0])
+		}
+	}
+	return ignoreHelp(commands[0].Run(args, stdio))
+}
+
+// ignoreHelp treats -h as success, the flag set has already printed the help
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// help lists the commands, or shows the flags of one
+func help(commands []Command, args []string, stdio IO) error {
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.Name == args[0] {
+				return ignoreHelp(c.Run([]string{"--help"}, IO{Stdin: stdio.Stdin, Stdout: stdio.Stdout, Stderr: stdio.Stdout}))
+			}
+		}
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	w := tabwriter.NewWriter(stdio.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "usage: straico-cli [command] [flags]\n\nCommands:\n")
+	for i, c := range commands {
+		summary := c.Summary
+		if i == 0 {
+			summary += " (default)"
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", c.Name, summary)
+	}
+	fmt.Fprintf(w, "\nRun straico-cli help <command> to see its flags.\n")
+	return w.Flush()
+}
+
+// NewFlagSet returns the flag set of a command. usage is printed above the flags by --help,
+// its first line being what follows "straico-cli".
+func NewFlagSet(name string, usage string, stdio IO) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	output := stdio.Stderr
+	if output == nil {
+		output = os.Stderr
+	}
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintf(output, "usage: straico-cli %s\n", strings.TrimSpace(usage))
+		if defaults := flags.FlagUsages(); defaults != "" {
+			fmt.Fprintf(output, "\nFlags:\n%s", defaults)
+		}
+	}
+	return flags
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	var ran []string
+	command := func(name string) Command {
+		return Command{Name: name, Summary: name + " things", Run: func(args []string, stdio IO) error {
+			flags := NewFlagSet(name, name+" [flags]", stdio)
+			verbose := flags.Bool("verbose", false, "Say more")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			if *verbose {
+				name += " verbosely"
+			}
+			ran = append(ran, name)
+			return nil
+		}}
+	}
+	commands := []Command{command("chat"), command("ask")}
+
+	var out bytes.Buffer
+	stdio := IO{Stdout: &out, Stderr: &out}
+	for _, args := range [][]string{nil, {"--verbose"}, {"ask"}, {"ask", "--verbose"}} {
+		if err := Execute(commands, args, stdio); err != nil {
+			t.Fatalf("Expected no error for %v, got %v", args, err)
+		}
+	}
+	want := []string{"chat", "chat verbosely", "ask", "ask verbosely"}
+	if strings.Join(ran, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, ran)
+	}
+
+	if err := Execute(commands, []string{"frobnicate"}, stdio); err == nil {
+		t.Error("Expected error for an unknown command")
+	}
+	if err := Execute(commands, []string{"ask", "--colour"}, stdio); err == nil {
+		t.Error("Expected error for an unknown flag")
+	}
+
+	out.Reset()
+	if err := Execute(commands, []string{"help"}, stdio); err != nil || !strings.Contains(out.String(), "chat things (default)") {
+		t.Errorf("Expected the command list, got %q, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := Execute(commands, []string{"ask", "--help"}, stdio); err != nil || !strings.Contains(out.String(), "--verbose") {
+		t.Errorf("Expected the flags of ask, got %q, %v", out.String(), err)
+	}
+	if err := Execute(commands, []string{"help", "frobnicate"}, stdio); err == nil {
+		t.Error("Expected error for help on an unknown command")
+	}
+}
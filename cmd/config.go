@@ -8,12 +8,149 @@ import (
 	"runtime"
 
 	"github.com/tyler71/straico-cli/m/v0/prompt"
+	"github.com/tyler71/straico-cli/m/v0/statefile"
 )
 
 type ConfigFile struct {
-	Key    string        `json:"key"`
-	Model  string        `json:"model"`
-	Prompt prompt.Prompt `json:"prompt"`
+	Key string `json:"key"`
+	// KeyCommand prints the API key, e.g. "pass show straico"
+	KeyCommand string        `json:"key_command,omitempty"`
+	Model      string        `json:"model"`
+	Prompt     prompt.Prompt `json:"prompt"`
+	Personas   []Persona     `json:"personas,omitempty"`
+	// Store is where conversations are saved, json (default) or sqlite
+	Store string `json:"store,omitempty"`
+	// Budget caps the coins of a prompt, a session and a day
+	Budget Budget `json:"budget"`
+	// BalanceWarning is the coin balance below which the chat warns
+	BalanceWarning float64            `json:"low_balance,omitempty"`
+	BaseURL        string             `json:"base_url,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
+	// DefaultProfile is used when neither --profile nor STRAICO_PROFILE is given
+	DefaultProfile string `json:"profile,omitempty"`
+	// ActiveProfile is the profile in use, set by UseProfile
+	ActiveProfile string `json:"-"`
+	// Persona is given with --persona, for the first conversation
+	Persona string `json:"-"`
+	// OverBudget sends prompts without asking when they may exceed the budget, set by --over-budget
+	OverBudget bool `json:"-"`
+	// origins tells which layer each setting came from, changes are written by SaveConfig
+	origins map[string]string
+	changes []change
+	// keySource is where ResolveKey found Key
+	keySource string
+	warning   string
+}
+
+// ProjectFile is the project config, found in the working directory or one of its parents
+const ProjectFile = ".straico.json"
+
+// Load builds the config from its layers, each overriding the previous one: defaults, the global config file,
+// the profile, the project file and STRAICO_* environment variables. Flags are left to the caller.
+// profile is the one given with --profile, create adds it to the global file when missing.
+func Load(profile string, create bool) (*ConfigFile, error) {
+	c := defaults()
+
+	var global ConfigFile
+	if err := global.LoadConfig(); err != nil {
+		return c, err
+	}
+	globalPath, err := global.path()
+	if err != nil {
+		return c, err
+	}
+	c.Prompt, c.Personas, c.Profiles, c.warning = global.Prompt, global.Personas, global.Profiles, global.warning
+	if err := c.apply(&global, globalPath); err != nil {
+		return c, err
+	}
+
+	project, projectPath, err := findProject()
+	if err != nil {
+		return c, err
+	}
+
+	// The profile is named by the highest layer, but applied right above the global file
+	fromFlag := profile != ""
+	switch {
+	case fromFlag:
+	case os.Getenv(ProfileEnv) != "":
+		profile = os.Getenv(ProfileEnv)
+	case project != nil && project.DefaultProfile != "":
+		profile = project.DefaultProfile
+	default:
+		profile = global.DefaultProfile
+	}
+	if profile != "" {
+		if err := c.UseProfile(profile, create); err != nil {
+			return c, err
+		}
+	}
+
+	if project != nil {
+		c.addWarning(project.warning)
+		if err := c.applyProject(project, projectPath); err != nil {
+			return c, err
+		}
+		c.Personas = mergePersonas(c.Personas, project.Personas)
+	}
+
+	for _, s := range Settings {
+		if value := os.Getenv(s.Env); value != "" {
+			if err := c.override(s, value, "env "+s.Env); err != nil {
+				return c, fmt.Errorf("env %s: %w", s.Env, err)
+			}
+		}
+	}
+	if fromFlag {
+		setting, _ := LookupSetting("profile")
+		c.override(setting, profile, "flag --profile")
+	}
+	return c, nil
+}
+
+// findProject reads the nearest project file, walking up from the working directory
+func findProject() (*ConfigFile, string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, "", nil
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var project ConfigFile
+			if err := json.Unmarshal(data, &project); err != nil {
+				return nil, "", fmt.Errorf("error parsing %s: %w", path, err)
+			}
+			if info, err := os.Stat(path); err == nil {
+				project.warning = project.insecureKeyWarning(path, info)
+			}
+			return &project, path, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("error reading %s: %w", path, err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
+// mergePersonas adds the project's personas, replacing global ones with the same name
+func mergePersonas(global, project []Persona) []Persona {
+	merged := make([]Persona, 0, len(global)+len(project))
+	for _, g := range global {
+		replaced := false
+		for _, p := range project {
+			replaced = replaced || p.Name == g.Name
+		}
+		if !replaced {
+			merged = append(merged, g)
+		}
+	}
+	return append(merged, project...)
 }
 
 func (c *ConfigFile) getConfigDir() (string, error) {
@@ -32,6 +169,16 @@ func (c *ConfigFile) getConfigDir() (string, error) {
 	}
 }
 
+// path is the global config file
+func (c *ConfigFile) path() (string, error) {
+	configDir, err := c.getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.json"), nil
+}
+
+// LoadConfig reads the global config file only, see Load for the effective config
 func (c *ConfigFile) LoadConfig() error {
 	configDir, err := c.getConfigDir()
 	if err != nil {
@@ -56,10 +203,14 @@ func (c *ConfigFile) LoadConfig() error {
 	if err := json.Unmarshal(data, c); err != nil {
 		return fmt.Errorf("error parsing config file: %w", err)
 	}
+	if info, err := os.Stat(configPath); err == nil {
+		c.warning = c.insecureKeyWarning(configPath, info)
+	}
 
 	return nil
 }
 
+// SaveConfig writes the changes made with Set to the global config file, leaving the rest of the file as is
 func (c *ConfigFile) SaveConfig() error {
 	configDir, err := c.getConfigDir()
 	if err != nil {
@@ -68,14 +219,39 @@ func (c *ConfigFile) SaveConfig() error {
 
 	configPath := filepath.Join(configDir, "config.json")
 
-	encodedConfig, err := json.MarshalIndent(c, "", "  ")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	unlock, err := statefile.Lock(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Other layers are not written, so start from the file as it is now
+	var file ConfigFile
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+	if err := c.replay(&file); err != nil {
+		return err
+	}
+
+	encodedConfig, err := json.MarshalIndent(&file, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error serializing config file: %w", err)
 	}
 
-	err = os.WriteFile(configPath, encodedConfig, 0644)
+	err = statefile.Write(configPath, encodedConfig, 0600)
 	if err != nil {
 		return fmt.Errorf("unable to write to config file %w", err)
 	}
+	c.changes = nil
 	return err
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -88,3 +89,128 @@ func TestLoadConfigNonExistent(t *testing.T) {
 		t.Errorf("Expected no error for non-existent config, got %v", err)
 	}
 }
+
+func TestLoadLayers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(KeyEnv, "")
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("STRAICO_MODEL", "")
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	globalPath := filepath.Join(configDir, "config.json")
+	global := `{"key": "global-key", "model": "openai/gpt-4.1", "store": "sqlite",
+		"personas": [{"name": "coder", "system_prompt": "global"}, {"name": "writer", "system_prompt": "global"}]}`
+	if err := os.WriteFile(globalPath, []byte(global), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// The project file is found from a subdirectory
+	project := filepath.Join(home, "project")
+	work := filepath.Join(project, "src", "pkg")
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	projectPath := filepath.Join(project, ProjectFile)
+	if err := os.WriteFile(projectPath, []byte(`{"model": "anthropic/claude-sonnet-4", "personas": [{"name": "coder", "system_prompt": "project"}]}`), 0600); err != nil {
+		t.Fatalf("Failed to write project file: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+	t.Setenv("STRAICO_STORE", "json")
+
+	config, err := Load("", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tests := []struct {
+		name, value, origin string
+	}{
+		{"key", "global-key", globalPath},
+		{"model", "anthropic/claude-sonnet-4", projectPath},
+		{"store", "json", "env STRAICO_STORE"},
+		{"base_url", DefaultBaseURL, OriginDefault},
+	}
+	for _, tt := range tests {
+		s, _ := LookupSetting(tt.name)
+		if got := s.Value(config); got != tt.value || config.Origin(tt.name) != tt.origin {
+			t.Errorf("Expected %s to be %q from %q, got %q from %q", tt.name, tt.value, tt.origin, got, config.Origin(tt.name))
+		}
+	}
+
+	if len(config.Personas) != 2 {
+		t.Fatalf("Expected 2 personas, got %d", len(config.Personas))
+	}
+	if p, _ := config.FindPersona("coder"); p.SystemPrompt != "project" {
+		t.Errorf("Expected the project's coder persona, got %q", p.SystemPrompt)
+	}
+
+	t.Setenv("STRAICO_STORE", "postgres")
+	if _, err := Load("", false); err == nil {
+		t.Error("Expected error for an invalid environment value")
+	}
+}
+
+func TestLoadProjectCannotSetSecrets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(KeyEnv, "")
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("STRAICO_MODEL", "")
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	globalPath := filepath.Join(configDir, "config.json")
+	if err := os.WriteFile(globalPath, []byte(`{"key": "global-key"}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// A cloned repository trying to run a command and send the key elsewhere
+	project := filepath.Join(home, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	projectPath := filepath.Join(project, ProjectFile)
+	hostile := `{"key": "their-key", "key_command": "touch pwned", "base_url": "http://127.0.0.1:18777",
+		"budget": {"request": 1000}, "model": "anthropic/claude-sonnet-4"}`
+	if err := os.WriteFile(projectPath, []byte(hostile), 0600); err != nil {
+		t.Fatalf("Failed to write project file: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	config, err := Load("", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, tt := range []struct{ name, value, origin string }{
+		{"key", "global-key", globalPath},
+		{"key_command", "", globalPath},
+		{"base_url", DefaultBaseURL, OriginDefault},
+		{"budget.request", "", OriginDefault},
+		{"model", "anthropic/claude-sonnet-4", projectPath},
+	} {
+		s, _ := LookupSetting(tt.name)
+		if got := s.Value(config); got != tt.value || config.Origin(tt.name) != tt.origin {
+			t.Errorf("Expected %s to be %q from %q, got %q from %q", tt.name, tt.value, tt.origin, got, config.Origin(tt.name))
+		}
+	}
+	if !strings.Contains(config.Warning(), "can't set key, key_command, base_url, budget.request, ignored") {
+		t.Errorf("Expected a warning about the ignored settings, got %q", config.Warning())
+	}
+}
@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tyler71/straico-cli/m/v0/statefile"
+)
+
+// ConfigCommand shows and changes the settings
+var ConfigCommand = Command{Name: "config", Summary: "Show and change settings", Run: RunConfig}
+
+const configUsage = `config show|get|set|unset|list|edit
+
+  show    print every setting's effective value, --origin tells where it came from
+  get     print the effective value of one setting
+  set     check a value and save it to the config file
+  unset   remove a setting from the config file
+  list    print the settings saved in the config file
+  edit    open the config file in $VISUAL or $EDITOR
+
+Run straico-cli config <command> --help to see its flags.`
+
+// RunConfig runs the config subcommand, args being what follows "config"
+func RunConfig(args []string, stdio IO) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		flags := NewFlagSet("config", configUsage, stdio)
+		flags.Usage()
+		if len(args) == 0 {
+			return errors.New("missing config command")
+		}
+		return nil
+	}
+	switch args[0] {
+	case "show":
+		return configShow(args[1:], stdio)
+	case "get":
+		return configGet(args[1:], stdio)
+	case "set":
+		return configSet(args[1:], stdio)
+	case "unset":
+		return configUnset(args[1:], stdio)
+	case "list":
+		return configList(args[1:], stdio)
+	case "edit":
+		return configEdit(args[1:], stdio)
+	default:
+		return fmt.Errorf("unknown config command %q, see straico-cli config --help", args[0])
+	}
+}
+
+// configShow prints the effective value of every setting, and with --origin the layer it came from
+func configShow(args []string, stdio IO) error {
+	flags := NewFlagSet("config show", `config show [flags]
+
+Prints the effective value of every setting, secrets masked.`, stdio)
+	origin := flags.Bool("origin", false, "Show where each value came from")
+	profile := flags.String("profile", "", "Show the config with this profile")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := Load(*profile, false)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(stdio.Stdout, 0, 0, 2, ' ', 0)
+	for _, s := range Settings {
+		value := s.Value(config)
+		if s.Secret {
+			value = mask(value)
+		}
+		if value == "" {
+			value = "(unset)"
+		}
+		if *origin {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, value, config.Origin(s.Name))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", s.Name, value)
+		}
+	}
+	return w.Flush()
+}
+
+// configGet prints the effective value of one setting
+func configGet(args []string, stdio IO) error {
+	flags := NewFlagSet("config get", `config get [flags] setting
+
+Prints the effective value of a setting.`, stdio)
+	profile := flags.String("profile", "", "Get the value with this profile")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: straico-cli config get [--profile name] setting")
+	}
+	s, err := LookupSetting(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	config, err := Load(*profile, false)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdio.Stdout, s.Value(config))
+	return err
+}
+
+// configSet validates a value and saves it to the config file
+func configSet(args []string, stdio IO) error {
+	flags := NewFlagSet("config set", `config set [flags] setting value
+
+Checks a value and saves it to the config file. Per-profile settings go to the profile in use.`, stdio)
+	profile := flags.String("profile", "", "Save to this profile, creating it if needed")
+	global := flags.Bool("global", false, "Save to the top level even when a profile is in use")
+	force := flags.Bool("force", false, "Save a model without checking it against the models API")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("usage: straico-cli config set [--profile name | --global] [--force] setting value")
+	}
+	name, value := flags.Arg(0), flags.Arg(1)
+	if value == "" {
+		return fmt.Errorf("use config unset to remove %s", name)
+	}
+
+	config, err := loadForSaving(*profile, *global, true)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "key":
+		where, err := config.SaveKey(value)
+		if err != nil {
+			return err
+		}
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdio.Stdout, "API key saved to the "+where)
+		return err
+	case "model":
+		if !*force {
+			if err := config.checkModel(value); err != nil {
+				return fmt.Errorf("%w, use --force to save it anyway", err)
+			}
+		}
+	case "profile":
+		if _, ok := config.Profiles[value]; !ok {
+			return fmt.Errorf("unknown profile %q, create it with config set --profile %s", value, value)
+		}
+	}
+	if err := config.Set(name, value); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdio.Stdout, "config saved")
+	return err
+}
+
+// configUnset removes a setting from the config file, so a lower layer or the default applies
+func configUnset(args []string, stdio IO) error {
+	flags := NewFlagSet("config unset", `config unset [flags] setting
+
+Removes a setting from the config file, so a lower layer or the default applies.`, stdio)
+	profile := flags.String("profile", "", "Remove from this profile")
+	global := flags.Bool("global", false, "Remove from the top level even when a profile is in use")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: straico-cli config unset [--profile name | --global] setting")
+	}
+
+	config, err := loadForSaving(*profile, *global, false)
+	if err != nil {
+		return err
+	}
+	if err := config.Set(flags.Arg(0), ""); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdio.Stdout, "config saved")
+	return err
+}
+
+// loadForSaving loads the config that changes are saved to, either the named profile, the top level or,
+// when neither is asked for, the profile in use
+func loadForSaving(profile string, global bool, create bool) (*ConfigFile, error) {
+	if profile != "" && global {
+		return nil, errors.New("--profile and --global can't be used together")
+	}
+	config, err := Load(profile, create)
+	if err != nil {
+		return nil, err
+	}
+	if global {
+		config.ActiveProfile = ""
+	}
+	return config, nil
+}
+
+// checkModel makes sure the API offers model, before config set or --save-model saves it
+func (c *ConfigFile) checkModel(model string) error {
+	if err := c.ResolveKey(); err != nil {
+		return err
+	}
+	modelsApi = c.APIBase() + "/v1/models"
+	models, err := GetModels(c.Key)
+	if err != nil {
+		return fmt.Errorf("unable to check the model: %w", err)
+	}
+	return unknownModel(models, model)
+}
+
+// configList prints the settings saved in the config file, including those of each profile
+func configList(args []string, stdio IO) error {
+	flags := NewFlagSet("config list", `config list
+
+Prints the settings saved in the config file, including those of each profile.`, stdio)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: straico-cli config list")
+	}
+	var file ConfigFile
+	if err := file.LoadConfig(); err != nil {
+		return err
+	}
+
+	list := func(prefix string, layer *ConfigFile, profileOnly bool) error {
+		for _, s := range Settings {
+			value := s.Value(layer)
+			if value == "" || (profileOnly && !s.Profile) {
+				continue
+			}
+			if s.Secret {
+				value = mask(value)
+			}
+			if _, err := fmt.Fprintf(stdio.Stdout, "%s%s=%s\n", prefix, s.Name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := list("", &file, false); err != nil {
+		return err
+	}
+	for _, name := range file.ProfileNames() {
+		layer := file.Profiles[name].config()
+		if err := list("profiles."+name+".", &layer, true); err != nil {
+			return err
+		}
+	}
+	if len(file.Personas) > 0 {
+		names := make([]string, len(file.Personas))
+		for i, p := range file.Personas {
+			names[i] = p.Name
+		}
+		if _, err := fmt.Fprintf(stdio.Stdout, "personas=%s\n", strings.Join(names, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configEdit opens a copy of the config file in $VISUAL or $EDITOR, and saves it once it is valid
+func configEdit(args []string, stdio IO) error {
+	flags := NewFlagSet("config edit", `config edit
+
+Opens the config file in $VISUAL or $EDITOR, and saves it once it is valid.`, stdio)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: straico-cli config edit")
+	}
+	var c ConfigFile
+	path, err := c.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	if len(original) == 0 {
+		original = []byte("{}\n")
+	}
+
+	// The copy keeps the .json extension for the editor's syntax highlighting
+	tmp, err := os.CreateTemp(filepath.Dir(path), "config.edit-*.json")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	tmp.Close()
+	if err := os.WriteFile(tmp.Name(), original, 0600); err != nil {
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := runEditor(tmp.Name()); err != nil {
+		return err
+	}
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("unable to read edited config: %w", err)
+	}
+	if slices.Equal(edited, original) {
+		os.Remove(tmp.Name())
+		_, err = fmt.Fprintln(stdio.Stdout, "config unchanged")
+		return err
+	}
+	if err := validateConfig(edited); err != nil {
+		return fmt.Errorf("%w, your changes are kept in %s", err, tmp.Name())
+	}
+
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := statefile.Write(path, edited, 0600); err != nil {
+		return fmt.Errorf("unable to write to config file %w", err)
+	}
+	os.Remove(tmp.Name())
+	_, err = fmt.Fprintln(stdio.Stdout, "config saved")
+	return err
+}
+
+// validateConfig checks every setting of a config file and its profiles
+func validateConfig(data []byte) error {
+	var file ConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	if err := defaults().apply(&file, "config file"); err != nil {
+		return err
+	}
+	for _, name := range file.ProfileNames() {
+		layer := file.Profiles[name].config()
+		if err := defaults().apply(&layer, "profile "+name); err != nil {
+			return err
+		}
+	}
+	if file.DefaultProfile != "" {
+		if _, ok := file.Profiles[file.DefaultProfile]; !ok {
+			return fmt.Errorf("config file: unknown profile %q", file.DefaultProfile)
+		}
+	}
+	return nil
+}
+
+// runEditor opens path in the user's editor and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		cmd = exec.Command("cmd", "/C", editor, path)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		// The editor may come with arguments, e.g. "code --wait"
+		cmd = exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+// mask hides all but the last 4 characters of a secret
+func mask(value string) string {
+	if len(value) <= 4 {
+		if value == "" {
+			return ""
+		}
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConfigShow(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(KeyEnv, "")
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("STRAICO_MODEL", "openai/gpt-4.1")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(home); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"key": "secret-key-1234"}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := RunConfig([]string{"show", "--origin"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[0], "****1234") || !strings.Contains(lines[0], configPath) || strings.Contains(out.String(), "secret") {
+		t.Errorf("Expected the masked key from the config file, got %q", lines[0])
+	}
+	if !strings.Contains(out.String(), "env STRAICO_MODEL") {
+		t.Errorf("Expected the model from the environment:\n%s", out.String())
+	}
+
+	if err := RunConfig([]string{"show", "--profile", "missing"}, IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for an unknown profile")
+	}
+	if err := RunConfig([]string{"frobnicate"}, IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for an unknown command")
+	}
+}
+
+func TestConfigSetAndUnset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("STRAICO_MODEL", "")
+	t.Setenv(KeyEnv, "test-key")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"chat": [{"name": "GPT 4.1", "model": "openai/gpt-4.1"}]}, "success": true}`))
+	}))
+	defer server.Close()
+	t.Setenv("STRAICO_BASE_URL", server.URL)
+
+	var out bytes.Buffer
+	if err := RunConfig([]string{"set", "model", "openai/gpt-4.1"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := RunConfig([]string{"set", "model", "openai/gpt-5-nano"}, IO{Stdout: &out}); err == nil || !strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("Expected error for a model the API doesn't offer, got %v", err)
+	}
+	if err := RunConfig([]string{"set", "--force", "--profile", "work", "model", "openai/gpt-5-nano"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected --force to skip the check, got %v", err)
+	}
+	if err := RunConfig([]string{"set", "prompt.max_tokens", "lots"}, IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for an invalid number")
+	}
+	if err := RunConfig([]string{"set", "colour", "blue"}, IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for an unknown setting")
+	}
+	if err := RunConfig([]string{"set", "prompt.file_urls", "https://a.example/x.pdf, https://b.example/y.pdf"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	out.Reset()
+	if err := RunConfig([]string{"list"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, line := range []string{"model=openai/gpt-4.1", "prompt.file_urls=https://a.example/x.pdf,https://b.example/y.pdf", "profiles.work.model=openai/gpt-5-nano"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, out.String())
+		}
+	}
+
+	if err := RunConfig([]string{"unset", "model"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out.Reset()
+	if err := RunConfig([]string{"get", "model"}, IO{Stdout: &out}); err != nil || out.String() != DefaultModel+"\n" {
+		t.Errorf("Expected the default model once unset, got %q, %v", out.String(), err)
+	}
+	out.Reset()
+	if err := RunConfig([]string{"get", "--profile", "work", "model"}, IO{Stdout: &out}); err != nil || out.String() != "openai/gpt-5-nano\n" {
+		t.Errorf("Expected the profile's model, got %q, %v", out.String(), err)
+	}
+}
+
+func TestConfigEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor is a sed command")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VISUAL", "")
+	t.Setenv("STRAICO_STORE", "")
+
+	var out bytes.Buffer
+	t.Setenv("EDITOR", `sed -i 's/{}/{"store": "sqlite"}/'`)
+	if err := RunConfig([]string{"edit"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	config, err := Load("", false)
+	if err != nil || config.Store != "sqlite" {
+		t.Errorf("Expected the edited store, got %q, %v", config.Store, err)
+	}
+
+	t.Setenv("EDITOR", `sed -i 's/sqlite/postgres/'`)
+	err = RunConfig([]string{"edit"}, IO{Stdout: &out})
+	if err == nil || !strings.Contains(err.Error(), "store must be json or sqlite") {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	if config, _ := Load("", false); config.Store != "sqlite" {
+		t.Errorf("Expected the invalid edit not to be saved, got %q", config.Store)
+	}
+	edits, _ := filepath.Glob(filepath.Join(home, ".config", "straico-cli", "config.edit-*.json"))
+	if len(edits) != 1 {
+		t.Errorf("Expected the invalid edit to be kept, got %v", edits)
+	}
+}
@@ -1,81 +1,182 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
+	"errors"
+	"io"
 	"os"
 
 	flag "github.com/spf13/pflag"
 )
 
-var (
-	model           string
-	apiKey          string
-	saveConfig      bool
-	saveModel       bool
-	listModels      bool
-	informationOnly bool
-	youtubeYourls   *[]string
-	fileUrls        *[]string
-)
+// PromptFlags are the flags shared by the commands that send prompts
+type PromptFlags struct {
+	Models      []string
+	Persona     string
+	Profile     string
+	YoutubeUrls []string
+	FileUrls    []string
+	OverBudget  bool
+	flags       *flag.FlagSet
+}
 
-func Init() *ConfigFile {
-	flag.BoolVar(&saveModel, "save-model", false, "Use the model listed by -m for future queries")
-	flag.StringVarP(&model, "model", "m", "openai/gpt-4.1-mini", "Model to use")
-	youtubeYourls = flag.StringSlice("youtube-url", nil, "--youtube-url link1 --youtube-url link2")
-	fileUrls = flag.StringSlice("file-url", nil, "--file-url link1 --file-url link2")
-	flag.BoolVarP(&listModels, "list-models", "l", false, "List models")
-	flag.StringVar(&apiKey, "save-key", "", "Straico API key")
-	flag.Parse()
+// AddPromptFlags registers the prompt flags on flags
+func AddPromptFlags(flags *flag.FlagSet) *PromptFlags {
+	p := &PromptFlags{flags: flags}
+	flags.StringSliceVarP(&p.Models, "model", "m", []string{DefaultModel}, "Model to use, repeat to compare several models")
+	flags.StringVar(&p.Persona, "persona", "", "Persona from the config file to use for the current conversation")
+	flags.StringVar(&p.Profile, "profile", "", "Config profile to use, defaults to $"+ProfileEnv)
+	flags.StringSliceVar(&p.YoutubeUrls, "youtube-url", nil, "--youtube-url link1 --youtube-url link2")
+	flags.StringSliceVar(&p.FileUrls, "file-url", nil, "--file-url link1 --file-url link2")
+	flags.BoolVar(&p.OverBudget, "over-budget", false, "Send prompts that may exceed a budget without asking")
+	return p
+}
 
-	modelFlagModified := flag.Lookup("model").Changed
+// Config loads the config of the profile and applies the flags over it, then checks the models exist.
+// Config file warnings go to stderr.
+func (p *PromptFlags) Config(stderr io.Writer) (*ConfigFile, error) {
+	configFile, err := loadWithKey(p.Profile, stderr)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.apply(configFile); err != nil {
+		return nil, err
+	}
+	if err := configFile.CheckModels(); err != nil {
+		return nil, err
+	}
+	return configFile, nil
+}
 
-	configFile := ConfigFile{}
-	err := configFile.LoadConfig()
+// loadWithKey loads the config of the profile, points the API urls at its base url and resolves the key.
+// Config file warnings and key errors go to stderr, the key may still be set with --save-key.
+func loadWithKey(profile string, stderr io.Writer) (*ConfigFile, error) {
+	configFile, err := Load(profile, false)
 	if err != nil {
-		_, _ = os.Stderr.Write([]byte(err.Error()))
+		return nil, err
 	}
-	if modelFlagModified {
-		configFile.Model = model
-		if saveModel {
-			saveConfig = true
-			informationOnly = true
-		}
+	modelsApi = configFile.APIBase() + "/v1/models"
+	userApi = configFile.APIBase() + "/v0/user"
+	configFile.Prompt.UrlPrefix = configFile.APIBase() + "/v1/prompt/completion"
+	if err := configFile.ResolveKey(); err != nil {
+		_, _ = io.WriteString(stderr, err.Error()+"\n")
 	}
-	if apiKey != "" {
-		saveConfig = true
-		configFile.Key = apiKey
+	if warning := configFile.Warning(); warning != "" {
+		_, _ = io.WriteString(stderr, warning+"\n")
 	}
+	return configFile, nil
+}
 
-	if saveConfig {
-		err := configFile.SaveConfig()
-		if err != nil {
-			log.Println("Unable to save config file")
-		} else {
-			_, _ = os.Stdout.Write([]byte("config saved\n"))
+// apply sets the model, persona and urls given as flags
+func (p *PromptFlags) apply(configFile *ConfigFile) error {
+	if p.flags.Changed("model") {
+		setting, _ := LookupSetting("model")
+		_ = configFile.override(setting, p.Models[0], "flag --model")
+		configFile.Prompt.Model = p.Models
+	} else {
+		configFile.Prompt.Model = []string{configFile.Model}
+	}
+	if p.flags.Changed("youtube-url") {
+		configFile.Prompt.YoutubeUrls = p.YoutubeUrls
+	}
+	if p.flags.Changed("file-url") {
+		configFile.Prompt.FileUrls = p.FileUrls
+	}
+	configFile.OverBudget = p.OverBudget
+	if p.Persona != "" {
+		if _, err := configFile.FindPersona(p.Persona); err != nil {
+			return err
 		}
+		configFile.Persona = p.Persona
 	}
+	return nil
+}
 
-	if listModels {
-		informationOnly = true
-		models, err := GetModels(configFile.Key)
-		if err != nil {
-			_, _ = os.Stderr.Write([]byte(err.Error()))
-		}
+const chatUsage = `[chat] [flags]
+
+Chat in the terminal UI. A prompt given with -p, a --output format or piped input answers a single prompt instead, like ask.`
+
+// ParseChat parses the flags of the chat command. The TUI lives outside this package, so the caller starts it
+// unless handled reports that the flags already did all there is to do, e.g. --list-models or a single prompt.
+func ParseChat(args []string, stdio IO) (configFile *ConfigFile, handled bool, err error) {
+	flags := NewFlagSet("chat", chatUsage, stdio)
+	promptFlags := AddPromptFlags(flags)
+	promptText := flags.StringP("prompt", "p", "", "Answer a single prompt and exit, piped stdin is appended")
+	outputFormat := flags.StringP("output", "o", OutputText, "Output format for single prompts: text, json or jsonl")
+	listModels := flags.BoolP("list-models", "l", false, "List models")
+	saveKey := flags.String("save-key", "", "Straico API key")
+	saveModel := flags.Bool("save-model", false, "Use the model listed by -m for future queries")
+	if err := flags.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if flags.NArg() > 0 {
+		return nil, false, errors.New("unexpected arguments, use -p or the ask command to send a prompt")
+	}
 
-		for _, m := range models {
-			outputString := fmt.Sprintf("%s\n\tModel: %s\n\tPricing: %d\n", m.Name, m.Id, m.Pricing)
-			_, _ = os.Stdout.Write([]byte(outputString))
+	if *saveKey != "" || *saveModel {
+		if err := saveFlags(promptFlags, *saveKey, *saveModel, stdio.Stdout); err != nil {
+			return nil, false, err
+		}
+		if *saveModel {
+			return nil, true, nil
 		}
 	}
 
-	if informationOnly {
-		os.Exit(0)
+	configFile, err = promptFlags.Config(stdio.Stderr)
+	if err != nil {
+		return nil, false, err
+	}
+	if *listModels {
+		return nil, true, writeModels(configFile, modelsOptions{kind: ModelsChat, output: OutputTable}, stdio.Stdout)
 	}
+	if *promptText != "" || *outputFormat != OutputText || !interactive(stdio) {
+		return nil, true, RunOneShot(configFile, *promptText, *outputFormat, stdio)
+	}
+	return configFile, false, nil
+}
 
-	configFile.Prompt.Model = []string{model}
-	configFile.Prompt.YoutubeUrls = *youtubeYourls
-	configFile.Prompt.FileUrls = *fileUrls
+// saveFlags handles --save-key and --save-model, which save to the profile in use and create it if needed
+func saveFlags(promptFlags *PromptFlags, key string, saveModel bool, stdout io.Writer) error {
+	if saveModel && !promptFlags.flags.Changed("model") {
+		return errors.New("--save-model needs the model to save, given with -m")
+	}
+	configFile, err := Load(promptFlags.Profile, true)
+	if err != nil {
+		return err
+	}
+	if saveModel {
+		// Checked like config set model, with the key being saved if one is given
+		if key != "" {
+			configFile.Key, configFile.KeyCommand = key, ""
+		}
+		if err := configFile.checkModel(promptFlags.Models[0]); err != nil {
+			return err
+		}
+		if err := configFile.Set("model", promptFlags.Models[0]); err != nil {
+			return err
+		}
+	}
+	savedKeyTo := ""
+	if key != "" {
+		if savedKeyTo, err = configFile.SaveKey(key); err != nil {
+			return err
+		}
+	}
+	if err := configFile.SaveConfig(); err != nil {
+		return err
+	}
+	_, _ = io.WriteString(stdout, "config saved\n")
+	if savedKeyTo != "" {
+		_, _ = io.WriteString(stdout, "API key saved to the "+savedKeyTo+"\n")
+	}
+	return nil
+}
 
-	return &configFile
+// interactive reports whether both stdin and stdout are terminals
+func interactive(stdio IO) bool {
+	stdin, ok := stdio.Stdin.(*os.File)
+	if !ok || !isTerminal(stdin) {
+		return false
+	}
+	stdout, ok := stdio.Stdout.(*os.File)
+	return ok && isTerminal(stdout)
 }
@@ -1,60 +1,109 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
-func TestInitWithDefaults(t *testing.T) {
-	// Save original args and restore them after the test
-	originalArgs := os.Args
-	defer func() { os.Args = originalArgs }()
+func TestPromptFlagsDefaults(t *testing.T) {
+	api := catalogServer(t)
+	t.Setenv("STRAICO_MODEL", "")
 
-	// Set minimal args for testing
-	os.Args = []string{"straico-cli"}
+	flags := NewFlagSet("chat", chatUsage, IO{})
+	promptFlags := AddPromptFlags(flags)
+	if err := flags.Parse(nil); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
 
-	// Create a temporary directory for config
-	tempDir, err := os.MkdirTemp("", "straico-cli-test-flags")
+	var stderr bytes.Buffer
+	config, err := promptFlags.Config(&stderr)
 	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Check default values
+	if config.Prompt.Model[0] != "openai/gpt-4.1-mini" {
+		t.Errorf("Expected default model 'openai/gpt-4.1-mini', got %q", config.Prompt.Model[0])
+	}
+
+	if len(config.Prompt.YoutubeUrls) != 0 {
+		t.Errorf("Expected empty YoutubeUrls, got %v", config.Prompt.YoutubeUrls)
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Create a mock config file
-	configPath := filepath.Join(tempDir, "config.json")
-	mockConfig := ConfigFile{
-		Key:   "",
-		Model: "",
+	if len(config.Prompt.FileUrls) != 0 {
+		t.Errorf("Expected empty FileUrls, got %v", config.Prompt.FileUrls)
 	}
 
-	encodedConfig, err := json.MarshalIndent(mockConfig, "", "  ")
+	if config.Prompt.UrlPrefix != api+"/v1/prompt/completion" {
+		t.Errorf("Expected the completion url of the base url, got %q", config.Prompt.UrlPrefix)
+	}
+}
+
+func TestPromptFlagsOverride(t *testing.T) {
+	catalogServer(t)
+	t.Setenv("STRAICO_MODEL", "openai/gpt-4.1")
+
+	flags := NewFlagSet("ask", askUsage, IO{})
+	promptFlags := AddPromptFlags(flags)
+	if err := flags.Parse([]string{"-m", "openai/gpt-4.1-mini", "-m", "anthropic/claude-sonnet-4", "--file-url", "https://example.com/a.pdf"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	config, err := promptFlags.Config(&bytes.Buffer{})
 	if err != nil {
-		t.Fatalf("Failed to marshal config: %v", err)
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(config.Prompt.Model) != 2 || config.Origin("model") != "flag --model" {
+		t.Errorf("Expected both models from the flag, got %v from %q", config.Prompt.Model, config.Origin("model"))
+	}
+	if len(config.Prompt.FileUrls) != 1 {
+		t.Errorf("Expected the file url, got %v", config.Prompt.FileUrls)
 	}
 
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		t.Fatalf("Failed to create config directory: %v", err)
+	promptFlags.Models = []string{"openai/gpt-4.1-mni"}
+	if _, err := promptFlags.Config(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "did you mean openai/gpt-4.1-mini") {
+		t.Errorf("Expected a suggestion for a typo, got %v", err)
 	}
 
-	if err := os.WriteFile(configPath, encodedConfig, 0644); err != nil {
-		t.Fatalf("Failed to write config file: %v", err)
+	promptFlags.Models = []string{"openai/gpt-4.1"}
+	promptFlags.Persona = "missing"
+	if _, err := promptFlags.Config(&bytes.Buffer{}); err == nil {
+		t.Error("Expected error for an unknown persona")
 	}
+}
 
-	// Call Init
-	config := Init()
+func TestParseChatSaveModel(t *testing.T) {
+	catalogServer(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
 
-	// Check default values
-	if config.Prompt.Model[0] != "anthropic/claude-3-haiku:beta" {
-		t.Errorf("Expected default model 'anthropic/claude-3-haiku:beta', got %q", config.Prompt.Model[0])
+	var stdout bytes.Buffer
+	if _, _, err := ParseChat([]string{"--save-model", "-m", "openai/gpt-5-nano"}, IO{Stdout: &stdout}); err == nil ||
+		!strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("Expected an unknown model to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "straico-cli", "config.json")); err == nil {
+		t.Error("Expected nothing saved for an unknown model")
 	}
 
-	if len(config.Prompt.YoutubeUrls) != 0 {
-		t.Errorf("Expected empty YoutubeUrls, got %v", config.Prompt.YoutubeUrls)
+	config, handled, err := ParseChat([]string{"--save-model", "-m", "openai/gpt-4.1"}, IO{Stdout: &stdout})
+	if err != nil || !handled || config != nil {
+		t.Fatalf("Expected the model to be saved without starting a chat, got %v, %v", handled, err)
+	}
+	if !strings.Contains(stdout.String(), "config saved") {
+		t.Errorf("Expected a confirmation, got %q", stdout.String())
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".config", "straico-cli", "config.json"))
+	if err != nil || !strings.Contains(string(data), `"model": "openai/gpt-4.1"`) {
+		t.Errorf("Expected the model in the config file, got %s, %v", data, err)
 	}
 
-	if len(config.Prompt.FileUrls) != 0 {
-		t.Errorf("Expected empty FileUrls, got %v", config.Prompt.FileUrls)
+	if _, _, err := ParseChat([]string{"--save-model"}, IO{Stdout: &stdout}); err == nil {
+		t.Error("Expected error for --save-model without -m")
+	}
+	if _, _, err := ParseChat([]string{"hello"}, IO{Stdout: &stdout}); err == nil {
+		t.Error("Expected error for a bare argument")
 	}
 }
@@ -0,0 +1,83 @@
+// Package history has the commands that read the saved conversations. They live apart from cmd
+// because they open the conversations through tui, which itself imports cmd.
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/export"
+	"github.com/tyler71/straico-cli/m/v0/tui"
+)
+
+// ExportCommand writes a saved conversation as markdown, html or json
+var ExportCommand = cmd.Command{Name: "export", Summary: "Write a saved conversation to a file", Run: runExport}
+
+const exportUsage = `export [flags]
+
+Writes a saved conversation as markdown, html or json.`
+
+// runExport writes a saved conversation to stdout, or to the file given with --file
+func runExport(args []string, stdio cmd.IO) error {
+	flags := cmd.NewFlagSet("export", exportUsage, stdio)
+	buffer := flags.IntP("buffer", "b", 1, "Quick slot to export, 1-9")
+	name := flags.StringP("conversation", "c", "", "Name or id of the conversation to export, instead of a quick slot")
+	format := flags.StringP("format", "f", "", "markdown, html or json (default from the file extension, otherwise markdown)")
+	file := flags.StringP("file", "o", "", "Write to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+		if *format == "" {
+			*format = export.FormatMarkdown
+		}
+	}
+	exportFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	config, err := cmd.Load("", false)
+	if err != nil {
+		return err
+	}
+	conversations, err := tui.NewConversations(config.Store)
+	if err != nil {
+		return err
+	}
+	defer conversations.Close()
+	var conversation *tui.Conversation
+	if *name != "" {
+		if conversation = conversations.Find(*name); conversation == nil {
+			return fmt.Errorf("no conversation named %q", *name)
+		}
+	} else {
+		if *buffer < 1 || *buffer > tui.SlotCount {
+			return fmt.Errorf("buffer must be between 1 and %d", tui.SlotCount)
+		}
+		// An unbound slot is reported, rather than exporting a conversation created on the spot
+		conversation = conversations.Find(conversations.Slots[*buffer-1])
+		if conversation == nil || len(conversation.Messages) == 0 {
+			return fmt.Errorf("buffer %d is empty", *buffer)
+		}
+	}
+	transcript := conversation.Transcript(conversation.Title())
+
+	if *file == "" {
+		return export.Write(stdio.Stdout, exportFormat, transcript)
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return fmt.Errorf("unable to create export file: %w", err)
+	}
+	defer f.Close()
+	if err := export.Write(f, exportFormat, transcript); err != nil {
+		return err
+	}
+	return f.Close()
+}
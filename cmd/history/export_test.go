@@ -0,0 +1,61 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+)
+
+func TestRunExport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	saved := `[{"prompt_history": ["Hello"], "messages": [
+		{"role": "user", "content": "Hello", "timestamp": "2025-01-02T03:04:05Z"},
+		{"role": "assistant", "content": "Hi there", "model": "test-model", "coins": 0.5, "timestamp": "2025-01-02T03:04:06Z"}
+	]}]`
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "conversations.json"), []byte(saved), 0644); err != nil {
+		t.Fatalf("Failed to write conversations file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runExport([]string{"--buffer", "1"}, cmd.IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "### test-model · 0.50 coins\n\nHi there") {
+		t.Errorf("Expected markdown export, got:\n%s", out.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "transcript.json")
+	if err := runExport([]string{"--file", file}, cmd.IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if !json.Valid(data) {
+		t.Errorf("Expected json export from the file extension, got:\n%s", data)
+	}
+
+	if err := runExport([]string{"--buffer", "2"}, cmd.IO{Stdout: &out}); err == nil || err.Error() != "buffer 2 is empty" {
+		t.Errorf("Expected an empty buffer to be an error, got %v", err)
+	}
+
+	if err := runExport([]string{"--buffer", "10"}, cmd.IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for buffer out of range")
+	}
+
+	if err := runExport([]string{"--conversation", "missing"}, cmd.IO{Stdout: &out}); err == nil {
+		t.Error("Expected error for unknown conversation")
+	}
+}
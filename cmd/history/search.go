@@ -0,0 +1,71 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/tui"
+)
+
+// SearchCommand finds the messages of the saved conversations containing every word
+var SearchCommand = cmd.Command{Name: "search", Summary: "Search saved conversations", Run: runSearch}
+
+const searchUsage = `search [flags] words...
+
+Finds the messages of saved conversations containing every word, best matches first.`
+
+// runSearch prints the messages of every saved conversation matching the query
+func runSearch(args []string, stdio cmd.IO) error {
+	flags := cmd.NewFlagSet("search", searchUsage, stdio)
+	limit := flags.IntP("limit", "n", 20, "Maximum number of matches to show, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	query := strings.Join(flags.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		return errors.New("usage: straico-cli search [--limit n] words...")
+	}
+
+	config, err := cmd.Load("", false)
+	if err != nil {
+		return err
+	}
+	conversations, err := tui.NewConversations(config.Store)
+	if err != nil {
+		return err
+	}
+	defer conversations.Close()
+	hits := conversations.Search(query)
+	if len(hits) == 0 {
+		return fmt.Errorf("no matches for %q", query)
+	}
+	if *limit > 0 && len(hits) > *limit {
+		hits = hits[:*limit]
+	}
+
+	// Styles are left out when stdout is not a terminal
+	highlight := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	for _, hit := range hits {
+		title := hit.Conversation.Title()
+		if slot := conversations.SlotOf(hit.Conversation); slot != -1 {
+			title = "F" + strconv.Itoa(slot+1) + " " + title
+		}
+		location := "prompt history"
+		if hit.Message >= 0 {
+			location = "message " + strconv.Itoa(hit.Message+1)
+		}
+		sender := "You: "
+		if hit.Role == tui.RoleAssistant {
+			sender = "LLM: "
+		}
+		fmt.Fprintf(stdio.Stdout, "%s (%s, %s, id %s)\n  %s%s\n",
+			titleStyle.Render(title), hit.Time.Format("2006-01-02 15:04"), location, hit.Conversation.ID,
+			sender, hit.Highlight(highlight))
+	}
+	return nil
+}
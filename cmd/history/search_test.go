@@ -0,0 +1,43 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+)
+
+func TestRunSearch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	saved := `{"conversations": [{"id": "abc", "name": "dates", "updated": "2025-01-02T03:04:06Z", "messages": [
+		{"role": "user", "content": "How do I match a date?", "timestamp": "2025-01-02T03:04:05Z"},
+		{"role": "assistant", "content": "Use a regex", "timestamp": "2025-01-02T03:04:06Z"}
+	]}], "slots": ["abc", "", "", "", "", "", "", "", ""]}`
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "conversations.json"), []byte(saved), 0644); err != nil {
+		t.Fatalf("Failed to write conversations file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runSearch([]string{"regex"}, cmd.IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "F1 dates") || !strings.Contains(out.String(), "message 2") || !strings.Contains(out.String(), "LLM: Use a regex") {
+		t.Errorf("Unexpected search output:\n%s", out.String())
+	}
+
+	if err := runSearch([]string{"missing"}, cmd.IO{Stdout: &out}); err == nil {
+		t.Error("Expected error when nothing matches")
+	}
+	if err := runSearch(nil, cmd.IO{Stdout: &out}); err == nil {
+		t.Error("Expected error without a query")
+	}
+}
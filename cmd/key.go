@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyEnv holds the API key, taking precedence over the config file
+const KeyEnv = "STRAICO_API_KEY"
+
+const keyringService = "straico-cli"
+
+// Where the API key was found, besides the layer that set it
+const (
+	KeyFromCommand = "key_command"
+	KeyFromConfig  = "config file"
+	KeyFromKeyring = "keyring"
+)
+
+// ResolveKey runs the key_command when one is set, otherwise looks the key up in the OS keyring
+// when no layer set one
+func (c *ConfigFile) ResolveKey() error {
+	switch {
+	case c.KeyCommand != "":
+		key, err := runKeyCommand(c.KeyCommand)
+		if err != nil {
+			return err
+		}
+		c.Key, c.keySource = key, KeyFromCommand
+	case c.Key != "":
+		c.keySource = c.Origin("key")
+	default:
+		key, err := keyring.Get(keyringService, c.keyringUser())
+		if err != nil {
+			// No key saved, or no keyring on this system
+			return nil
+		}
+		c.Key, c.keySource = key, KeyFromKeyring
+	}
+	return nil
+}
+
+// KeySource tells where ResolveKey found the key, empty when there is none
+func (c *ConfigFile) KeySource() string {
+	return c.keySource
+}
+
+// SaveKey stores key in the OS keyring, or in the config file when there is no keyring.
+// Either way the config file needs saving afterwards, to remove or add the plaintext key.
+func (c *ConfigFile) SaveKey(key string) (where string, err error) {
+	if err := keyring.Set(keyringService, c.keyringUser(), key); err == nil {
+		if err := c.Set("key", ""); err != nil {
+			return "", err
+		}
+		c.Key = key
+		return KeyFromKeyring, nil
+	}
+	return KeyFromConfig, c.Set("key", key)
+}
+
+// keyringUser separates the keys of each profile
+func (c *ConfigFile) keyringUser() string {
+	if c.ActiveProfile != "" {
+		return c.ActiveProfile
+	}
+	return "default"
+}
+
+// runKeyCommand returns the trimmed output of command, run by the shell
+func runKeyCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("key_command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("key_command printed no key")
+	}
+	return key, nil
+}
+
+// insecureKeyWarning explains the risk when a config file holding a plaintext key can be read by other users
+func (c *ConfigFile) insecureKeyWarning(path string, info os.FileInfo) string {
+	if runtime.GOOS == "windows" || info.Mode().Perm()&0o004 == 0 {
+		return ""
+	}
+	hasKey := c.Key != ""
+	for _, p := range c.Profiles {
+		hasKey = hasKey || p.Key != ""
+	}
+	if !hasKey {
+		return ""
+	}
+	return fmt.Sprintf("warning: %s holds an API key and is readable by other users, run chmod 600 on it or "+
+		"move the key to the keyring with --save-key", path)
+}
+
+// Warning is a problem with the config files worth telling the user about, if any
+func (c *ConfigFile) Warning() string {
+	return c.warning
+}
+
+// addWarning adds a line to the warning, if not empty
+func (c *ConfigFile) addWarning(warning string) {
+	switch {
+	case warning == "":
+	case c.warning == "":
+		c.warning = warning
+	default:
+		c.warning += "\n" + warning
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolveKey(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(KeyEnv, "")
+
+	config := ConfigFile{Key: "plain-key", origins: map[string]string{"key": "/home/config.json"}}
+	if err := config.ResolveKey(); err != nil || config.Key != "plain-key" || config.KeySource() != "/home/config.json" {
+		t.Errorf("Expected the config file key, got %q from %q, %v", config.Key, config.KeySource(), err)
+	}
+
+	config = ConfigFile{KeyCommand: "echo command-key"}
+	if err := config.ResolveKey(); err != nil || config.Key != "command-key" || config.KeySource() != KeyFromCommand {
+		t.Errorf("Expected the key_command key, got %q from %q, %v", config.Key, config.KeySource(), err)
+	}
+
+	config = ConfigFile{KeyCommand: "exit 1"}
+	if err := config.ResolveKey(); err == nil {
+		t.Error("Expected error for a failing key_command")
+	}
+
+	// The environment is a higher layer than the file's key_command
+	config = ConfigFile{}
+	if err := config.Set("key_command", "echo command-key"); err != nil || config.SaveConfig() != nil {
+		t.Fatalf("Failed to save key_command: %v", err)
+	}
+	t.Setenv(KeyEnv, "env-key")
+	loaded, err := Load("", false)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if err := loaded.ResolveKey(); err != nil || loaded.Key != "env-key" || loaded.KeySource() != "env "+KeyEnv {
+		t.Errorf("Expected the environment key, got %q from %q, %v", loaded.Key, loaded.KeySource(), err)
+	}
+}
+
+func TestSaveKeyToKeyring(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(KeyEnv, "")
+
+	config, err := Load("work", true)
+	if err != nil {
+		t.Fatalf("Expected a new profile, got %v", err)
+	}
+	if where, err := config.SaveKey("work-key"); err != nil || where != KeyFromKeyring {
+		t.Fatalf("Expected the key in the keyring, got %q, %v", where, err)
+	}
+	if err := config.SaveConfig(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	config, err = Load("work", false)
+	if err != nil || config.Key != "" {
+		t.Fatalf("Expected no key in the config file, got %q, %v", config.Key, err)
+	}
+	if err := config.ResolveKey(); err != nil || config.Key != "work-key" || config.KeySource() != KeyFromKeyring {
+		t.Errorf("Expected the profile's keyring key, got %q from %q, %v", config.Key, config.KeySource(), err)
+	}
+}
+
+func TestInsecureKeyWarning(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	path := filepath.Join(configDir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"key": "plain-key"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var config ConfigFile
+	if err := config.LoadConfig(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !strings.Contains(config.Warning(), "readable by other users") {
+		t.Errorf("Expected a warning for a world readable key, got %q", config.Warning())
+	}
+
+	if err := config.SaveConfig(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the config to be saved with mode 0600, got %v, %v", info.Mode().Perm(), err)
+	}
+	config = ConfigFile{}
+	if err := config.LoadConfig(); err != nil || config.Warning() != "" {
+		t.Errorf("Expected no warning once private, got %q, %v", config.Warning(), err)
+	}
+}
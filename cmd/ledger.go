@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+	"github.com/tyler71/straico-cli/m/v0/statefile"
+)
+
+// ledgerFile keeps a line for every answer, it is only ever appended to
+const ledgerFile = "usage.jsonl"
+
+// Sources of the answers in the ledger
+const (
+	SourceChat = "chat"
+	SourceAsk  = "ask"
+)
+
+// UsageEntry is an answer recorded in the ledger
+type UsageEntry struct {
+	Time   time.Time           `json:"time"`
+	Model  string              `json:"model"`
+	Price  prompt.OverallPrice `json:"price"`
+	Words  prompt.OverallPrice `json:"words"`
+	Tokens int64               `json:"tokens,omitempty"`
+	Source string              `json:"source"`
+	// Conversation is the id of the chat buffer that asked, empty for ask
+	Conversation string `json:"conversation,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+}
+
+// UsageEntries lists the answers of result, each model being charged separately
+func UsageEntries(result prompt.Result, source string, conversation string, profile string) []UsageEntry {
+	now := time.Now()
+	entries := make([]UsageEntry, 0, len(result.Models))
+	for _, m := range result.Models {
+		entries = append(entries, UsageEntry{
+			Time: now, Model: m.Model, Price: m.Price, Words: m.Words, Tokens: m.Usage.TotalTokens,
+			Source: source, Conversation: conversation, Profile: profile,
+		})
+	}
+	return entries
+}
+
+// RecordUsage appends the entries to the ledger
+func RecordUsage(entries []UsageEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path, err := ledgerPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	var data []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("error serializing usage: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	unlock, err := statefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open usage ledger: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write usage ledger: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadUsage returns every entry of the ledger, oldest first. Lines that can't be read are skipped.
+func ReadUsage() ([]UsageEntry, error) {
+	path, err := ledgerPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []UsageEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e UsageEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read usage ledger: %w", err)
+	}
+	return entries, nil
+}
+
+func ledgerPath() (string, error) {
+	var c ConfigFile
+	configDir, err := c.getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, ledgerFile), nil
+}
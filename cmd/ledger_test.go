@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestRecordUsage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	result := prompt.Result{Models: []prompt.ModelResult{
+		{Model: "openai/gpt-4.1", Price: prompt.OverallPrice{Total: 1.5}, Words: prompt.OverallPrice{Total: 30}},
+		{Model: "anthropic/claude-sonnet-4", Price: prompt.OverallPrice{Total: 4}, Words: prompt.OverallPrice{Total: 25}},
+	}}
+	if err := RecordUsage(UsageEntries(result, SourceChat, "abc", "work")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := RecordUsage(UsageEntries(result, SourceAsk, "", "")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A broken line, e.g. from a crash, doesn't hide the others
+	path := filepath.Join(home, ".config", "straico-cli", ledgerFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time": "broken`)
+	f.Close()
+
+	entries, err := ReadUsage()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	if e := entries[1]; e.Model != "anthropic/claude-sonnet-4" || e.Price.Total != 4 || e.Conversation != "abc" || e.Profile != "work" {
+		t.Errorf("Expected claude's answer in conversation abc, got %+v", e)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the ledger to be private, got %v", info.Mode())
+	}
+}
@@ -2,52 +2,55 @@ package cmd
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 )
 
-const modelsApi = "https://api.straico.com/v1/models"
+// modelsApi follows the base url of the active profile
+var modelsApi = DefaultBaseURL + "/v1/models"
 
 type Models struct {
-	Name    string
-	Id      string
-	Pricing int
+	Name string
+	Id   string
+	// Pricing is the coins charged per PricingWords words
+	Pricing      float64
+	PricingWords int64
+	WordLimit    int64
+	MaxOutput    int64
 }
 
+// GetModels returns the chat models of the API
 func GetModels(apiKey string) ([]Models, error) {
-	client := &http.Client{}
-	req, _ := http.NewRequest("GET", modelsApi, nil)
-	req.Header = http.Header{
-		"Authorization": []string{"Bearer " + apiKey},
-		"Accept":        []string{"application/json"},
-	}
-	resp, _ := client.Do(req)
-	defer resp.Body.Close()
-
-	if resp.StatusCode > 299 || resp.StatusCode < 200 {
-		errorMessage := fmt.Errorf("request failed. Error: %s", resp.Status)
-		return nil, errorMessage
-	}
-	bodyText, err := io.ReadAll(resp.Body)
-
-	straicoModels, err := UnmarshalStraicoModels(bodyText)
+	straicoModels, err := FetchModels(apiKey)
 	if err != nil {
-		errorMessage := fmt.Errorf("request failed. Error: %w", err)
-		return nil, errorMessage
+		return nil, err
 	}
 	chatModels := straicoModels.Data.Chat
 	viableModels := make([]Models, len(chatModels))
 	for i := range chatModels {
 		viableModels[i] = Models{
-			Name:    chatModels[i].Name,
-			Id:      chatModels[i].Model,
-			Pricing: int(chatModels[i].Pricing.Coins),
+			Name:         chatModels[i].Name,
+			Id:           chatModels[i].Model,
+			Pricing:      chatModels[i].Pricing.Coins,
+			PricingWords: chatModels[i].Pricing.Words,
+			WordLimit:    chatModels[i].WordLimit,
+			MaxOutput:    chatModels[i].MaxOutput,
 		}
 	}
 	return viableModels, nil
 }
 
+// ContextLimits returns the smallest word limit among the selected models and the max output of that model.
+// Both are 0 when none of the selected models are known.
+func ContextLimits(models []Models, selected []string) (wordLimit int64, maxOutput int64) {
+	for _, id := range selected {
+		for _, m := range models {
+			if m.Id == id && m.WordLimit > 0 && (wordLimit == 0 || m.WordLimit < wordLimit) {
+				wordLimit, maxOutput = m.WordLimit, m.MaxOutput
+			}
+		}
+	}
+	return wordLimit, maxOutput
+}
+
 func UnmarshalStraicoModels(data []byte) (ModelsResponse, error) {
 	var r ModelsResponse
 	err := json.Unmarshal(data, &r)
@@ -83,7 +83,10 @@ func TestGetModels(t *testing.T) {
 			"Authorization": []string{"Bearer " + apiKey},
 			"Accept":        []string{"application/json"},
 		}
-		resp, _ := client.Do(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode > 299 || resp.StatusCode < 200 {
@@ -106,7 +109,7 @@ func TestGetModels(t *testing.T) {
 			viableModels[i] = Models{
 				Name:    chatModels[i].Name,
 				Id:      chatModels[i].Model,
-				Pricing: int(chatModels[i].Pricing.Coins),
+				Pricing: chatModels[i].Pricing.Coins,
 			}
 		}
 		return viableModels, nil
@@ -131,8 +134,8 @@ func TestGetModels(t *testing.T) {
 		t.Errorf("Expected Id 'test-model-1', got %q", models[0].Id)
 	}
 
-	if models[0].Pricing != 10 {
-		t.Errorf("Expected Pricing 10, got %d", models[0].Pricing)
+	if models[0].Pricing != 10.5 {
+		t.Errorf("Expected Pricing 10.5, got %v", models[0].Pricing)
 	}
 
 	// Check second model
@@ -144,8 +147,8 @@ func TestGetModels(t *testing.T) {
 		t.Errorf("Expected Id 'test-model-2', got %q", models[1].Id)
 	}
 
-	if models[1].Pricing != 20 {
-		t.Errorf("Expected Pricing 20, got %d", models[1].Pricing)
+	if models[1].Pricing != 20.5 {
+		t.Errorf("Expected Pricing 20.5, got %v", models[1].Pricing)
 	}
 }
 
@@ -218,3 +221,20 @@ func TestUnmarshalStraicoModels(t *testing.T) {
 		t.Errorf("Expected Pricing.Coins 10.5, got %f", chatModel.Pricing.Coins)
 	}
 }
+
+func TestContextLimits(t *testing.T) {
+	models := []Models{
+		{Id: "small", WordLimit: 1000, MaxOutput: 100},
+		{Id: "large", WordLimit: 8000, MaxOutput: 800},
+	}
+
+	wordLimit, maxOutput := ContextLimits(models, []string{"large", "small"})
+	if wordLimit != 1000 || maxOutput != 100 {
+		t.Errorf("Expected limits of the smallest model 1000/100, got %d/%d", wordLimit, maxOutput)
+	}
+
+	wordLimit, maxOutput = ContextLimits(models, []string{"unknown"})
+	if wordLimit != 0 || maxOutput != 0 {
+		t.Errorf("Expected 0/0 for unknown model, got %d/%d", wordLimit, maxOutput)
+	}
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ModelsCommand lists the models of the API
+var ModelsCommand = Command{Name: "models", Summary: "List the available models", Run: runModels}
+
+const modelsUsage = `models [flags] [filter]
+
+Lists the models the API offers with their price in coins. The filter matches part of a model's id or name.`
+
+// Model kinds accepted by --type
+const (
+	ModelsChat  = "chat"
+	ModelsImage = "image"
+	ModelsAll   = "all"
+)
+
+// Orders accepted by --sort
+const (
+	SortName    = "name"
+	SortPrice   = "price"
+	SortContext = "context"
+)
+
+// Output formats of the models command, besides OutputJSON
+const (
+	OutputTable = "table"
+	OutputCSV   = "csv"
+)
+
+// modelEntry is a chat or image model as listed by the models command
+type modelEntry struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	// Coins are charged per Words words of a chat model
+	Coins     float64 `json:"coins,omitempty"`
+	Words     int64   `json:"words,omitempty"`
+	WordLimit int64   `json:"word_limit,omitempty"`
+	MaxOutput int64   `json:"max_output,omitempty"`
+	// Sizes are the prices of an image model
+	Sizes []imageSize `json:"sizes,omitempty"`
+}
+
+type imageSize struct {
+	Orientation string `json:"orientation"`
+	Size        string `json:"size"`
+	Coins       int64  `json:"coins"`
+}
+
+// modelsOptions are the flags of the models command
+type modelsOptions struct {
+	filter   string
+	provider string
+	kind     string
+	sort     string
+	reverse  bool
+	output   string
+	refresh  bool
+}
+
+func runModels(args []string, stdio IO) error {
+	flags := NewFlagSet("models", modelsUsage, stdio)
+	profile := flags.String("profile", "", "Config profile to use, defaults to $"+ProfileEnv)
+	var options modelsOptions
+	flags.StringVar(&options.provider, "provider", "", "Only list models of this provider, e.g. openai")
+	flags.StringVarP(&options.kind, "type", "t", ModelsChat, "Models to list: chat, image or all")
+	flags.StringVarP(&options.sort, "sort", "s", "", "Sort by name, price or context, instead of the API's order")
+	flags.BoolVarP(&options.reverse, "reverse", "r", false, "Reverse the order")
+	flags.StringVarP(&options.output, "output", "o", OutputTable, "Output format: table, json or csv")
+	flags.BoolVar(&options.refresh, "refresh", false, "Ask the API instead of using the models cached in the last day")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options.filter = strings.Join(flags.Args(), " ")
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	config, err := loadWithKey(*profile, stdio.Stderr)
+	if err != nil {
+		return err
+	}
+	return writeModels(config, options, stdio.Stdout)
+}
+
+// validate checks the flags before the API is asked
+func (o modelsOptions) validate() error {
+	switch o.kind {
+	case ModelsChat, ModelsImage, ModelsAll:
+	default:
+		return fmt.Errorf("unknown model type %q, expected chat, image or all", o.kind)
+	}
+	switch o.sort {
+	case "", SortName, SortPrice, SortContext:
+	default:
+		return fmt.Errorf("unknown sort %q, expected name, price or context", o.sort)
+	}
+	switch o.output {
+	case OutputTable, OutputJSON, OutputCSV:
+	default:
+		return fmt.Errorf("unknown output format %q, expected table, json or csv", o.output)
+	}
+	return nil
+}
+
+// writeModels fetches the models and prints those matching options
+func writeModels(config *ConfigFile, options modelsOptions, stdout io.Writer) error {
+	fetch := FetchModels
+	if options.refresh {
+		fetch = RefreshModels
+	}
+	straicoModels, err := fetch(config.Key)
+	if err != nil {
+		return err
+	}
+	entries := options.apply(modelEntries(straicoModels))
+	switch options.output {
+	case OutputJSON:
+		return writeModelsJSON(stdout, entries)
+	case OutputCSV:
+		return writeModelsCSV(stdout, entries)
+	default:
+		return writeModelsTable(stdout, entries)
+	}
+}
+
+// modelEntries lists the chat models, then the image models
+func modelEntries(r ModelsResponse) []modelEntry {
+	entries := make([]modelEntry, 0, len(r.Data.Chat)+len(r.Data.Image))
+	for _, m := range r.Data.Chat {
+		entries = append(entries, modelEntry{
+			Type: ModelsChat, ID: m.Model, Name: m.Name, Provider: provider(m.Model),
+			Coins: m.Pricing.Coins, Words: m.Pricing.Words, WordLimit: m.WordLimit, MaxOutput: m.MaxOutput,
+		})
+	}
+	for _, m := range r.Data.Image {
+		entry := modelEntry{Type: ModelsImage, ID: m.Model, Name: m.Name, Provider: provider(m.Model)}
+		for _, size := range []struct {
+			orientation string
+			price       Landscape
+		}{{"square", m.Pricing.Square}, {"landscape", m.Pricing.Landscape}, {"portrait", m.Pricing.Portrait}} {
+			if size.price.Size != "" {
+				entry.Sizes = append(entry.Sizes, imageSize{Orientation: size.orientation, Size: size.price.Size, Coins: size.price.Coins})
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// provider is the part of a model id before the slash, e.g. openai for openai/gpt-4.1
+func provider(id string) string {
+	if before, _, found := strings.Cut(id, "/"); found {
+		return before
+	}
+	return ""
+}
+
+// apply filters and sorts the entries
+func (o modelsOptions) apply(entries []modelEntry) []modelEntry {
+	filter := strings.ToLower(o.filter)
+	matching := entries[:0:0]
+	for _, e := range entries {
+		switch {
+		case o.kind != ModelsAll && e.Type != o.kind:
+		case o.provider != "" && !strings.EqualFold(e.Provider, o.provider):
+		case filter != "" && !strings.Contains(strings.ToLower(e.ID), filter) && !strings.Contains(strings.ToLower(e.Name), filter):
+		default:
+			matching = append(matching, e)
+		}
+	}
+
+	compare := map[string]func(a, b modelEntry) int{
+		SortName:    func(a, b modelEntry) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+		SortPrice:   func(a, b modelEntry) int { return cmp.Compare(a.price(), b.price()) },
+		SortContext: func(a, b modelEntry) int { return cmp.Compare(a.WordLimit, b.WordLimit) },
+	}[o.sort]
+	if compare != nil {
+		slices.SortStableFunc(matching, compare)
+	}
+	if o.reverse {
+		slices.Reverse(matching)
+	}
+	return matching
+}
+
+// price makes chat models comparable by coins per 100 words, image models by their cheapest size
+func (e modelEntry) price() float64 {
+	if e.Type == ModelsImage {
+		cheapest := 0.0
+		for i, size := range e.Sizes {
+			if i == 0 || float64(size.Coins) < cheapest {
+				cheapest = float64(size.Coins)
+			}
+		}
+		return cheapest
+	}
+	if e.Words == 0 {
+		return e.Coins
+	}
+	return e.Coins * 100 / float64(e.Words)
+}
+
+func writeModelsTable(stdout io.Writer, entries []modelEntry) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	chat, image := false, false
+	for _, e := range entries {
+		if e.Type != ModelsChat {
+			continue
+		}
+		if !chat {
+			fmt.Fprintln(w, "ID\tNAME\tCOINS\tPER WORDS\tCONTEXT WORDS\tMAX OUTPUT")
+			chat = true
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", e.ID, e.Name, formatCoins(e.Coins), e.Words, e.WordLimit, e.MaxOutput)
+	}
+	for _, e := range entries {
+		if e.Type != ModelsImage {
+			continue
+		}
+		if !image {
+			if chat {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, "ID\tNAME\tSQUARE\tLANDSCAPE\tPORTRAIT")
+			image = true
+		}
+		prices := map[string]string{}
+		for _, size := range e.Sizes {
+			prices[size.Orientation] = fmt.Sprintf("%d (%s)", size.Coins, size.Size)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.ID, e.Name, prices["square"], prices["landscape"], prices["portrait"])
+	}
+	if !chat && !image {
+		fmt.Fprintln(w, "no models match")
+	}
+	return w.Flush()
+}
+
+func writeModelsJSON(stdout io.Writer, entries []modelEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing models: %w", err)
+	}
+	_, err = stdout.Write(append(data, '\n'))
+	return err
+}
+
+// writeModelsCSV writes a row per chat model and per image size
+func writeModelsCSV(stdout io.Writer, entries []modelEntry) error {
+	w := csv.NewWriter(stdout)
+	w.Write([]string{"type", "id", "name", "provider", "coins", "words", "word_limit", "max_output", "size"})
+	for _, e := range entries {
+		if e.Type == ModelsImage {
+			for _, size := range e.Sizes {
+				w.Write([]string{e.Type, e.ID, e.Name, e.Provider, strconv.FormatInt(size.Coins, 10), "", "", "", size.Orientation + " " + size.Size})
+			}
+			continue
+		}
+		w.Write([]string{e.Type, e.ID, e.Name, e.Provider, formatCoins(e.Coins), strconv.FormatInt(e.Words, 10),
+			strconv.FormatInt(e.WordLimit, 10), strconv.FormatInt(e.MaxOutput, 10), ""})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatCoins drops needless decimals, 0.5 stays 0.5 and 2 stays 2
+func formatCoins(coins float64) string {
+	return strconv.FormatFloat(coins, 'f', -1, 64)
+}
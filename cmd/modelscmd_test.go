@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testCatalog = `{
+	"data": {
+		"chat": [
+			{"name": "GPT 4.1 Mini", "model": "openai/gpt-4.1-mini", "word_limit": 50000, "pricing": {"coins": 0.5, "words": 100}, "max_output": 16000},
+			{"name": "Claude Sonnet 4", "model": "anthropic/claude-sonnet-4", "word_limit": 150000, "pricing": {"coins": 5, "words": 100}, "max_output": 48000},
+			{"name": "GPT 4.1", "model": "openai/gpt-4.1", "word_limit": 750000, "pricing": {"coins": 3, "words": 100}, "max_output": 24000}
+		],
+		"image": [
+			{"name": "DALL-E 3", "model": "openai/dall-e-3", "pricing": {
+				"square": {"coins": 90, "size": "1024x1024"},
+				"landscape": {"coins": 120, "size": "1792x1024"},
+				"portrait": {"coins": 120, "size": "1024x1792"}
+			}}
+		]
+	},
+	"success": true
+}`
+
+// catalogServer serves testCatalog as the API of a fresh config, returning its url
+func catalogServer(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testCatalog))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnv, "")
+	t.Setenv(KeyEnv, "test-key")
+	t.Setenv("STRAICO_BASE_URL", server.URL)
+	return server.URL
+}
+
+func TestRunModelsTable(t *testing.T) {
+	catalogServer(t)
+
+	var out bytes.Buffer
+	if err := runModels([]string{"--sort", "price"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 chat models, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "openai/gpt-4.1-mini") || !strings.Contains(lines[1], "0.5") {
+		t.Errorf("Expected the cheapest model first with its fractional price, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "anthropic/claude-sonnet-4") {
+		t.Errorf("Expected the most expensive model last, got %q", lines[3])
+	}
+
+	out.Reset()
+	if err := runModels([]string{"--type", "image"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "90 (1024x1024)") || !strings.Contains(out.String(), "120 (1024x1792)") {
+		t.Errorf("Expected the price of each image size, got:\n%s", out.String())
+	}
+}
+
+func TestRunModelsFilter(t *testing.T) {
+	catalogServer(t)
+
+	var out bytes.Buffer
+	if err := runModels([]string{"--provider", "openai", "--sort", "context", "--reverse", "-o", "json", "gpt"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var entries []modelEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to parse json: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != "openai/gpt-4.1" || entries[0].WordLimit != 750000 {
+		t.Errorf("Expected the openai chat models, largest context first, got %+v", entries)
+	}
+
+	out.Reset()
+	if err := runModels([]string{"--type", "all", "-o", "csv", "DALL"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse csv: %v", err)
+	}
+	if len(records) != 4 || records[1][0] != "image" || records[1][8] != "square 1024x1024" {
+		t.Errorf("Expected a row per image size, got %v", records)
+	}
+
+	for _, args := range [][]string{{"--sort", "speed"}, {"--type", "video"}, {"-o", "yaml"}} {
+		if err := runModels(args, IO{Stdout: &out}); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+// Output formats accepted by --output
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputJSONL = "jsonl"
+)
+
+// AskCommand answers a single prompt without the TUI
+var AskCommand = Command{Name: "ask", Summary: "Answer a single prompt and exit", Run: runAsk}
+
+const askUsage = `ask [flags] [prompt...]
+
+Answers a single prompt and exits. Piped stdin is appended to the prompt.
+Only the answer is written to stdout, the coins used go to stderr.`
+
+func runAsk(args []string, stdio IO) error {
+	flags := NewFlagSet("ask", askUsage, stdio)
+	promptFlags := AddPromptFlags(flags)
+	outputFormat := flags.StringP("output", "o", OutputText, "Output format: text, json or jsonl")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := promptFlags.Config(stdio.Stderr)
+	if err != nil {
+		return err
+	}
+	return RunOneShot(config, strings.Join(flags.Args(), " "), *outputFormat, stdio)
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// RunOneShot sends one request built from text and piped stdin.
+// Only the answer is written to stdout in the output format, coin usage goes to stderr.
+func RunOneShot(config *ConfigFile, text string, outputFormat string, stdio IO) error {
+	stdout, stderr := stdio.Stdout, stdio.Stderr
+	switch outputFormat {
+	case OutputText, OutputJSON, OutputJSONL:
+	default:
+		return fmt.Errorf("unknown output format %q, expected text, json or jsonl", outputFormat)
+	}
+
+	response, models, err := oneShotRequest(config, text, stdio.Stdin)
+	if err != nil {
+		if outputFormat != OutputText {
+			_ = writeResult(stdout, outputFormat, prompt.ErrorResult(err))
+		}
+		return err
+	}
+
+	result := prompt.NewResult(response, models)
+	if err := RecordUsage(UsageEntries(result, SourceAsk, "", config.ActiveProfile)); err != nil {
+		_, _ = io.WriteString(stderr, "unable to record usage: "+err.Error()+"\n")
+	}
+	if outputFormat == OutputText {
+		err = writeText(stdout, result)
+	} else {
+		err = writeResult(stdout, outputFormat, result)
+	}
+	if err != nil {
+		return err
+	}
+	_, _ = io.WriteString(stderr, strconv.FormatFloat(response.Data.OverallPrice.Total, 'f', 2, 64)+" coins used.\n")
+	return nil
+}
+
+// oneShotRequest returns the response along with the models that were asked
+func oneShotRequest(config *ConfigFile, text string, stdin io.Reader) (prompt.StraicoResponse, []string, error) {
+	message, err := oneShotMessage(text, stdin)
+	if err != nil {
+		return prompt.StraicoResponse{}, nil, err
+	}
+	if config.Key == "" {
+		return prompt.StraicoResponse{}, nil, errors.New("no API key configured, set one with --save-key")
+	}
+
+	p := config.Prompt
+	if config.Persona != "" {
+		persona, err := config.FindPersona(config.Persona)
+		if err != nil {
+			return prompt.StraicoResponse{}, nil, err
+		}
+		p = persona.Apply(p)
+	}
+	if err := config.checkBudget(p, message); err != nil {
+		return prompt.StraicoResponse{}, nil, err
+	}
+
+	response, err := p.Request(config.Key, message, nil)
+	if err != nil {
+		return prompt.StraicoResponse{}, nil, err
+	}
+	if len(response.Data.Completions) == 0 {
+		return prompt.StraicoResponse{}, nil, errors.New("no completions returned")
+	}
+	return response, p.Model, nil
+}
+
+// oneShotMessage combines the prompt text with anything piped on stdin.
+// stdin is only read when it is not a terminal.
+func oneShotMessage(text string, stdin io.Reader) (string, error) {
+	var piped string
+	if f, ok := stdin.(*os.File); !ok || !isTerminal(f) {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("unable to read stdin: %w", err)
+		}
+		piped = strings.TrimSpace(string(data))
+	}
+
+	switch {
+	case text != "" && piped != "":
+		return text + "\n\n" + piped, nil
+	case text != "":
+		return text, nil
+	case piped != "":
+		return piped, nil
+	}
+	return "", errors.New("no prompt given, pass it as an argument or pipe text on stdin")
+}
+
+// writeText writes the bare answer, or each answer under a model header when several models were asked.
+func writeText(w io.Writer, result prompt.Result) error {
+	for i, m := range result.Models {
+		var text string
+		if len(result.Models) > 1 {
+			if i > 0 {
+				text = "\n"
+			}
+			text += "== " + m.Model + " ==\n"
+		}
+		text += strings.TrimRight(m.Content, "\n") + "\n"
+		if _, err := io.WriteString(w, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResult writes json as a single indented document and jsonl as one compact line,
+// so repeated runs can be appended to the same file.
+func writeResult(w io.Writer, format string, result prompt.Result) error {
+	var data []byte
+	var err error
+	if format == OutputJSON {
+		data, err = json.MarshalIndent(result, "", "  ")
+	} else {
+		data, err = json.Marshal(result)
+	}
+	if err != nil {
+		return fmt.Errorf("error serializing result: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestOneShotMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		stdin string
+		want  string
+	}{
+		{"prompt only", "summarize", "", "summarize"},
+		{"stdin only", "", "diff --git a b\n", "diff --git a b"},
+		{"prompt and stdin", "summarize", "diff --git a b\n", "summarize\n\ndiff --git a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := oneShotMessage(tt.text, strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := oneShotMessage("", strings.NewReader("")); err == nil {
+		t.Error("Expected error for empty prompt")
+	}
+}
+
+func TestRunOneShot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"overall_price": {"input": 0.1, "output": 0.2, "total": 0.3},
+				"overall_words": {"input": 10, "output": 20, "total": 30},
+				"completions": {
+					"test-model": {
+						"completion": {
+							"choices": [{"message": {"role": "assistant", "content": "Test response"}, "finish_reason": "stop"}]
+						}
+					}
+				}
+			},
+			"success": true
+		}`))
+	}))
+	defer server.Close()
+
+	config := ConfigFile{
+		Key:    "test-key",
+		Prompt: prompt.Prompt{Model: []string{"test-model"}, UrlPrefix: server.URL},
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := RunOneShot(&config, "Test message", OutputText, IO{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stderr}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stdout.String() != "Test response\n" {
+		t.Errorf("Expected stdout %q, got %q", "Test response\n", stdout.String())
+	}
+	if stderr.String() != "0.30 coins used.\n" {
+		t.Errorf("Expected stderr %q, got %q", "0.30 coins used.\n", stderr.String())
+	}
+	entries, err := ReadUsage()
+	if err != nil || len(entries) != 1 || entries[0].Model != "test-model" || entries[0].Source != SourceAsk {
+		t.Errorf("Expected the answer in the usage ledger, got %+v, %v", entries, err)
+	}
+}
+
+func TestRunOneShotJSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"overall_price": {"input": 0.1, "output": 0.2, "total": 0.3},
+				"overall_words": {"input": 10, "output": 20, "total": 30},
+				"completions": {
+					"test-model": {
+						"completion": {
+							"choices": [{"message": {"role": "assistant", "content": "Test response"}, "finish_reason": "stop"}],
+							"usage": {"prompt_tokens": 1, "completion_tokens": 2, "total_tokens": 3}
+						},
+						"price": {"input": 0.1, "output": 0.2, "total": 0.3},
+						"words": {"input": 10, "output": 20, "total": 30}
+					}
+				}
+			},
+			"success": true
+		}`))
+	}))
+	defer server.Close()
+
+	config := ConfigFile{
+		Key:    "test-key",
+		Prompt: prompt.Prompt{Model: []string{"test-model"}, UrlPrefix: server.URL},
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := RunOneShot(&config, "Test message", OutputJSONL, IO{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stderr}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Count(stdout.String(), "\n") != 1 {
+		t.Errorf("Expected a single jsonl line, got %q", stdout.String())
+	}
+
+	var result prompt.Result
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if len(result.Models) != 1 || result.Models[0].Content != "Test response" {
+		t.Fatalf("Expected one model with content 'Test response', got %+v", result.Models)
+	}
+	if result.Models[0].FinishReason != "stop" {
+		t.Errorf("Expected FinishReason 'stop', got %q", result.Models[0].FinishReason)
+	}
+	if result.Models[0].Usage.TotalTokens != 3 {
+		t.Errorf("Expected Usage.TotalTokens 3, got %d", result.Models[0].Usage.TotalTokens)
+	}
+	if result.Price.Total != 0.3 {
+		t.Errorf("Expected Price.Total 0.3, got %f", result.Price.Total)
+	}
+}
+
+func TestRunOneShotHTTPError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	config := ConfigFile{
+		Key:    "bad-key",
+		Prompt: prompt.Prompt{Model: []string{"test-model"}, UrlPrefix: server.URL},
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := RunOneShot(&config, "Test message", OutputText, IO{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stderr}); err == nil {
+		t.Fatal("Expected error for unauthorized response")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected empty stdout, got %q", stdout.String())
+	}
+}
+
+func TestWriteTextMultipleModels(t *testing.T) {
+	result := prompt.Result{Models: []prompt.ModelResult{
+		{Model: "model-a", Content: "Answer A"},
+		{Model: "model-b", Content: "Answer B\n"},
+	}}
+
+	var out bytes.Buffer
+	if err := writeText(&out, result); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := "== model-a ==\nAnswer A\n\n== model-b ==\nAnswer B\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
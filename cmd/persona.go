@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+// Persona is a reusable system prompt with optional request defaults
+type Persona struct {
+	Name         string `json:"name"`
+	SystemPrompt string `json:"system_prompt"`
+	Model        string `json:"model,omitempty"`
+	MaxTokens    int    `json:"max_tokens,omitempty"`
+}
+
+// Apply returns a copy of p using the persona's system prompt, model and token limit
+func (persona Persona) Apply(p prompt.Prompt) prompt.Prompt {
+	p.System = persona.SystemPrompt
+	if persona.Model != "" {
+		p.Model = []string{persona.Model}
+	}
+	if persona.MaxTokens > 0 {
+		p.MaxToken = persona.MaxTokens
+	}
+	return p
+}
+
+// FindPersona looks up a persona from the config file by name
+func (c *ConfigFile) FindPersona(name string) (Persona, error) {
+	for _, p := range c.Personas {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Persona{}, fmt.Errorf("unknown persona %q", name)
+}
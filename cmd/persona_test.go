@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestPersonaApply(t *testing.T) {
+	persona := Persona{Name: "reviewer", SystemPrompt: "You review Go code.", Model: "persona-model", MaxTokens: 500}
+	original := prompt.Prompt{Model: []string{"default-model"}}
+
+	p := persona.Apply(original)
+
+	if p.System != "You review Go code." {
+		t.Errorf("Expected System %q, got %q", persona.SystemPrompt, p.System)
+	}
+	if len(p.Model) != 1 || p.Model[0] != "persona-model" {
+		t.Errorf("Expected Model [persona-model], got %v", p.Model)
+	}
+	if p.MaxToken != 500 {
+		t.Errorf("Expected MaxToken 500, got %d", p.MaxToken)
+	}
+	if original.Model[0] != "default-model" || original.System != "" {
+		t.Errorf("Expected original prompt to be unchanged, got %+v", original)
+	}
+}
+
+func TestPersonaApplyKeepsDefaults(t *testing.T) {
+	p := Persona{Name: "terse", SystemPrompt: "Be terse."}.Apply(prompt.Prompt{Model: []string{"a", "b"}, MaxToken: 100})
+
+	if len(p.Model) != 2 {
+		t.Errorf("Expected models to be kept, got %v", p.Model)
+	}
+	if p.MaxToken != 100 {
+		t.Errorf("Expected MaxToken 100, got %d", p.MaxToken)
+	}
+}
+
+func TestFindPersona(t *testing.T) {
+	config := ConfigFile{Personas: []Persona{{Name: "reviewer"}, {Name: "translator"}}}
+
+	if p, err := config.FindPersona("translator"); err != nil || p.Name != "translator" {
+		t.Errorf("Expected translator persona, got %+v, %v", p, err)
+	}
+	if _, err := config.FindPersona("missing"); err == nil {
+		t.Error("Expected error for unknown persona")
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+// ProfileEnv selects a profile, overriding the config files
+const ProfileEnv = "STRAICO_PROFILE"
+
+// DefaultBaseURL is the Straico API used when neither the config nor the profile sets one
+const DefaultBaseURL = "https://api.straico.com"
+
+// Profile is a named account. Settings it leaves out are taken from the global config file,
+// except for the key which belongs to the account.
+type Profile struct {
+	Key        string         `json:"key,omitempty"`
+	KeyCommand string         `json:"key_command,omitempty"`
+	Model      string         `json:"model,omitempty"`
+	Prompt     *prompt.Prompt `json:"prompt,omitempty"`
+	BaseURL    string         `json:"base_url,omitempty"`
+}
+
+// ProfileNames lists the profiles of the config file in alphabetical order
+func (c *ConfigFile) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
+// UseProfile applies the named profile over the global config file.
+// When create is set, a missing profile is added instead of being an error.
+func (c *ConfigFile) UseProfile(name string, create bool) error {
+	profile, ok := c.Profiles[name]
+	if !ok && !create {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q, no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.ActiveProfile = name
+
+	origin := "profile " + name
+	layer := profile.config()
+	if err := c.apply(&layer, origin); err != nil {
+		return err
+	}
+	// The key belongs to the account, a profile without one does not use the global key
+	if profile.Key == "" && profile.KeyCommand == "" {
+		for _, name := range []string{"key", "key_command"} {
+			s, _ := LookupSetting(name)
+			_ = c.override(s, "", origin)
+		}
+	}
+	return nil
+}
+
+// APIBase is the base url of the Straico API, without a trailing slash
+func (c *ConfigFile) APIBase() string {
+	if c.BaseURL != "" {
+		return strings.TrimRight(c.BaseURL, "/")
+	}
+	return DefaultBaseURL
+}
+
+// config returns the profile's settings as a config file layer
+func (p Profile) config() ConfigFile {
+	c := ConfigFile{Key: p.Key, KeyCommand: p.KeyCommand, Model: p.Model, BaseURL: p.BaseURL}
+	if p.Prompt != nil {
+		c.Prompt = *p.Prompt
+	}
+	return c
+}
+
+// profileFrom is the inverse of config: it keeps every setting of a layer read from a profile,
+// the whole prompt included, so changing one setting leaves the profile's other defaults alone
+func profileFrom(c ConfigFile) Profile {
+	p := Profile{Key: c.Key, KeyCommand: c.KeyCommand, Model: c.Model, BaseURL: c.BaseURL}
+	if !reflect.ValueOf(c.Prompt).IsZero() {
+		p.Prompt = &c.Prompt
+	}
+	return p
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestUseProfile(t *testing.T) {
+	config := ConfigFile{
+		Key:    "personal-key",
+		Model:  "openai/gpt-4.1-mini",
+		Prompt: prompt.Prompt{MaxToken: 100},
+		Profiles: map[string]Profile{
+			"work": {Key: "work-key", Prompt: &prompt.Prompt{MaxToken: 500}, BaseURL: "https://straico.example.com/"},
+		},
+	}
+
+	if err := config.UseProfile("missing", false); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+	if err := config.UseProfile("work", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if config.Key != "work-key" || config.Prompt.MaxToken != 500 || config.ActiveProfile != "work" {
+		t.Errorf("Expected the profile's key and prompt defaults, got %q %d %q", config.Key, config.Prompt.MaxToken, config.ActiveProfile)
+	}
+	if config.Model != "openai/gpt-4.1-mini" {
+		t.Errorf("Expected the model to fall back to the top level, got %q", config.Model)
+	}
+	if got := config.APIBase(); got != "https://straico.example.com" {
+		t.Errorf("Expected the profile's base url, got %q", got)
+	}
+}
+
+func TestSaveConfigWritesActiveProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"key": "personal-key", "model": "openai/gpt-4.1"}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := Load("work", true)
+	if err != nil {
+		t.Fatalf("Expected a new profile, got %v", err)
+	}
+	if err := config.Set("key", "work-key"); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+	if err := config.SaveConfig(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".config", "straico-cli", "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var saved ConfigFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if saved.Key != "personal-key" {
+		t.Errorf("Expected the top level key to be kept, got %q", saved.Key)
+	}
+	if saved.Model != "openai/gpt-4.1" {
+		t.Errorf("Expected the top level model to be kept, got %q", saved.Model)
+	}
+	if profile := saved.Profiles["work"]; profile.Key != "work-key" || profile.Model != "" {
+		t.Errorf("Expected only the key in the work profile, got %+v", profile)
+	}
+}
+
+func TestSaveConfigKeepsProfilePrompt(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	config := `{"key": "personal-key", "profiles": {"work": {"key": "work-key",
+		"prompt": {"max_tokens": 500, "file_urls": ["https://example.com/spec.pdf"]}}}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loaded, err := Load("work", false)
+	if err != nil {
+		t.Fatalf("Failed to load the work profile: %v", err)
+	}
+	if err := loaded.Set("model", "anthropic/claude-sonnet-4"); err != nil {
+		t.Fatalf("Failed to set model: %v", err)
+	}
+	if err := loaded.SaveConfig(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var saved ConfigFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	profile := saved.Profiles["work"]
+	if profile.Model != "anthropic/claude-sonnet-4" || profile.Key != "work-key" {
+		t.Errorf("Expected the model added to the work profile, got %+v", profile)
+	}
+	if profile.Prompt == nil || profile.Prompt.MaxToken != 500 || len(profile.Prompt.FileUrls) != 1 {
+		t.Errorf("Expected the profile's prompt defaults to be kept, got %+v", profile.Prompt)
+	}
+}
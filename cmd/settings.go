@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultModel is used when no layer sets a model
+const DefaultModel = "openai/gpt-4.1-mini"
+
+// Origins of a setting's value, besides the config files
+const (
+	OriginDefault = "default"
+	OriginSet     = "set"
+)
+
+// Setting is a config value that every layer can set: the config files, a profile and the environment
+type Setting struct {
+	// Name is the json key, nested keys are joined with a dot
+	Name string
+	// Env overrides the files
+	Env string
+	// Default is the value when no layer sets it
+	Default string
+	// Secret values are masked when shown
+	Secret bool
+	// Profile settings can be set per profile
+	Profile bool
+	// Project settings can be set by a project file, which comes with a repository and isn't trusted
+	Project bool
+	// Clears is the setting this one replaces when set in the same or a higher layer
+	Clears string
+	get    func(c *ConfigFile) string
+	set    func(c *ConfigFile, value string) error
+}
+
+// Settings in the order config show lists them
+var Settings = []Setting{
+	{
+		Name: "key", Env: KeyEnv, Secret: true, Profile: true, Clears: "key_command",
+		get: func(c *ConfigFile) string { return c.Key },
+		set: func(c *ConfigFile, v string) error { c.Key = v; return nil },
+	},
+	{
+		Name: "key_command", Env: "STRAICO_KEY_COMMAND", Profile: true, Clears: "key",
+		get: func(c *ConfigFile) string { return c.KeyCommand },
+		set: func(c *ConfigFile, v string) error { c.KeyCommand = v; return nil },
+	},
+	{
+		Name: "model", Env: "STRAICO_MODEL", Default: DefaultModel, Profile: true, Project: true,
+		get: func(c *ConfigFile) string { return c.Model },
+		set: func(c *ConfigFile, v string) error { c.Model = v; return nil },
+	},
+	{
+		Name: "prompt.max_tokens", Env: "STRAICO_MAX_TOKENS", Profile: true, Project: true,
+		get: func(c *ConfigFile) string {
+			if c.Prompt.MaxToken == 0 {
+				return ""
+			}
+			return strconv.Itoa(c.Prompt.MaxToken)
+		},
+		set: func(c *ConfigFile, v string) error {
+			if v == "" {
+				c.Prompt.MaxToken = 0
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("prompt.max_tokens must be a positive number, got %q", v)
+			}
+			c.Prompt.MaxToken = n
+			return nil
+		},
+	},
+	{
+		Name: "prompt.file_urls", Env: "STRAICO_FILE_URLS", Project: true,
+		get: func(c *ConfigFile) string { return strings.Join(c.Prompt.FileUrls, ",") },
+		set: func(c *ConfigFile, v string) error { c.Prompt.FileUrls = splitList(v); return nil },
+	},
+	{
+		Name: "prompt.youtube_urls", Env: "STRAICO_YOUTUBE_URLS", Project: true,
+		get: func(c *ConfigFile) string { return strings.Join(c.Prompt.YoutubeUrls, ",") },
+		set: func(c *ConfigFile, v string) error { c.Prompt.YoutubeUrls = splitList(v); return nil },
+	},
+	{
+		Name: "base_url", Env: "STRAICO_BASE_URL", Default: DefaultBaseURL, Profile: true,
+		get: func(c *ConfigFile) string { return c.BaseURL },
+		set: func(c *ConfigFile, v string) error {
+			if v != "" {
+				if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("base_url must be an http or https url, got %q", v)
+				}
+			}
+			c.BaseURL = v
+			return nil
+		},
+	},
+	{
+		Name: "store", Env: "STRAICO_STORE", Default: "json",
+		get: func(c *ConfigFile) string { return c.Store },
+		set: func(c *ConfigFile, v string) error {
+			if v != "" && v != "json" && v != "sqlite" {
+				return fmt.Errorf("store must be json or sqlite, got %q", v)
+			}
+			c.Store = v
+			return nil
+		},
+	},
+	coinSetting("budget.request", "STRAICO_BUDGET_REQUEST", func(c *ConfigFile) *float64 { return &c.Budget.Request }),
+	coinSetting("budget.session", "STRAICO_BUDGET_SESSION", func(c *ConfigFile) *float64 { return &c.Budget.Session }),
+	coinSetting("budget.day", "STRAICO_BUDGET_DAY", func(c *ConfigFile) *float64 { return &c.Budget.Day }),
+	projectSetting(coinSetting("low_balance", "STRAICO_LOW_BALANCE", func(c *ConfigFile) *float64 { return &c.BalanceWarning })),
+	{
+		Name: "profile", Env: ProfileEnv, Project: true,
+		get: func(c *ConfigFile) string { return c.DefaultProfile },
+		set: func(c *ConfigFile, v string) error { c.DefaultProfile = v; return nil },
+	},
+}
+
+// splitList reads a comma separated list, nil when empty
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// LookupSetting finds a setting by name
+func LookupSetting(name string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting %q", name)
+}
+
+// Value returns the setting's value in c
+func (s Setting) Value(c *ConfigFile) string {
+	return s.get(c)
+}
+
+// defaults returns a config holding only the default values
+func defaults() *ConfigFile {
+	c := &ConfigFile{origins: make(map[string]string)}
+	for _, s := range Settings {
+		s.set(c, s.Default)
+		c.origins[s.Name] = OriginDefault
+	}
+	return c
+}
+
+// apply copies the settings layer sets onto c, noting origin as where they came from
+func (c *ConfigFile) apply(layer *ConfigFile, origin string) error {
+	// key_command comes after key, so within a layer the command wins
+	for _, s := range Settings {
+		value := s.get(layer)
+		if value == "" {
+			continue
+		}
+		if err := c.override(s, value, origin); err != nil {
+			return fmt.Errorf("%s: %w", origin, err)
+		}
+	}
+	return nil
+}
+
+// applyProject applies the settings a project file may set. The others are ignored with a warning:
+// a cloned repository must not run a key command, replace the key or send it to another server.
+func (c *ConfigFile) applyProject(project *ConfigFile, origin string) error {
+	var ignored []string
+	for _, s := range Settings {
+		value := s.get(project)
+		switch {
+		case value == "":
+		case !s.Project:
+			ignored = append(ignored, s.Name)
+		default:
+			if err := c.override(s, value, origin); err != nil {
+				return fmt.Errorf("%s: %w", origin, err)
+			}
+		}
+	}
+	if len(ignored) > 0 {
+		c.addWarning(fmt.Sprintf("warning: %s can't set %s, ignored", origin, strings.Join(ignored, ", ")))
+	}
+	return nil
+}
+
+// override sets a single setting, replacing any lower layer
+func (c *ConfigFile) override(s Setting, value string, origin string) error {
+	if err := s.set(c, value); err != nil {
+		return err
+	}
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	c.origins[s.Name] = origin
+	if s.Clears != "" && value != "" {
+		cleared, _ := LookupSetting(s.Clears)
+		cleared.set(c, "")
+		c.origins[cleared.Name] = origin
+	}
+	return nil
+}
+
+// Origin tells where the effective value of the named setting came from
+func (c *ConfigFile) Origin(name string) string {
+	if origin, ok := c.origins[name]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// change is a setting to write to the config file on the next save
+type change struct {
+	name  string
+	value string
+}
+
+// Set changes a setting now and in the config file on the next save, an empty value removes it.
+// While a profile is in use, settings that can be set per profile are saved to that profile.
+func (c *ConfigFile) Set(name, value string) error {
+	s, err := LookupSetting(name)
+	if err != nil {
+		return err
+	}
+	effective, origin := value, OriginSet
+	if value == "" {
+		effective, origin = s.Default, OriginDefault
+	}
+	if err := c.override(s, effective, origin); err != nil {
+		return err
+	}
+	c.changes = append(c.changes, change{name: name, value: value})
+	return nil
+}
+
+// replay applies the pending changes to file, as read from disk
+func (c *ConfigFile) replay(file *ConfigFile) error {
+	for _, ch := range c.changes {
+		s, err := LookupSetting(ch.name)
+		if err != nil {
+			return err
+		}
+		target := file
+		var profile ConfigFile
+		if c.ActiveProfile != "" && s.Profile {
+			profile = file.Profiles[c.ActiveProfile].config()
+			target = &profile
+		}
+		if err := s.set(target, ch.value); err != nil {
+			return err
+		}
+		if ch.value != "" && s.Clears != "" {
+			cleared, _ := LookupSetting(s.Clears)
+			cleared.set(target, "")
+		}
+		if target == &profile {
+			if file.Profiles == nil {
+				file.Profiles = make(map[string]Profile)
+			}
+			file.Profiles[c.ActiveProfile] = profileFrom(profile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// UsageCommand reports the coins spent, from the ledger every answer is recorded in
+var UsageCommand = Command{Name: "usage", Summary: "Report the coins spent by day, week or model", Run: runUsage}
+
+const usageUsage = `usage [flags]
+
+Reports the coins spent on the answers of every chat and ask, by day, week, month or model.
+A --rate converts the coins to a currency, e.g. --rate 0.0001 --currency USD.`
+
+// Groupings accepted by --by
+const (
+	ByDay   = "day"
+	ByWeek  = "week"
+	ByMonth = "month"
+	ByModel = "model"
+)
+
+const dateLayout = "2006-01-02"
+
+// usageOptions are the flags of the usage command
+type usageOptions struct {
+	by       string
+	since    time.Time
+	until    time.Time
+	profile  string
+	model    string
+	rate     float64
+	currency string
+	output   string
+}
+
+// usageRow totals the answers of a day, week, month or model
+type usageRow struct {
+	Group   string  `json:"group"`
+	Answers int     `json:"answers"`
+	Words   float64 `json:"words"`
+	Coins   float64 `json:"coins"`
+	Cost    float64 `json:"cost,omitempty"`
+}
+
+func runUsage(args []string, stdio IO) error {
+	flags := NewFlagSet("usage", usageUsage, stdio)
+	var options usageOptions
+	flags.StringVarP(&options.by, "by", "b", ByDay, "Group by day, week, month or model")
+	since := flags.String("since", "", "Only count answers from this date on, as YYYY-MM-DD")
+	until := flags.String("until", "", "Only count answers up to and including this date, as YYYY-MM-DD")
+	flags.StringVar(&options.profile, "profile", "", "Only count answers asked with this config profile")
+	flags.StringVarP(&options.model, "model", "m", "", "Only count answers of this model")
+	flags.Float64Var(&options.rate, "rate", 0, "Price of a coin, adds a cost column")
+	flags.StringVar(&options.currency, "currency", "", "Currency of --rate, e.g. USD")
+	flags.StringVarP(&options.output, "output", "o", OutputTable, "Output format: table, json or csv")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", strings.Join(flags.Args(), " "))
+	}
+	if err := options.parse(*since, *until); err != nil {
+		return err
+	}
+
+	entries, err := ReadUsage()
+	if err != nil {
+		return err
+	}
+	rows, total := options.report(entries)
+	switch options.output {
+	case OutputJSON:
+		return writeUsageJSON(stdio.Stdout, rows, total)
+	case OutputCSV:
+		return options.writeCSV(stdio.Stdout, rows)
+	default:
+		return options.writeTable(stdio.Stdout, rows, total)
+	}
+}
+
+// parse checks the flags and reads the dates
+func (o *usageOptions) parse(since, until string) error {
+	switch o.by {
+	case ByDay, ByWeek, ByMonth, ByModel:
+	default:
+		return fmt.Errorf("unknown grouping %q, expected day, week, month or model", o.by)
+	}
+	switch o.output {
+	case OutputTable, OutputJSON, OutputCSV:
+	default:
+		return fmt.Errorf("unknown output format %q, expected table, json or csv", o.output)
+	}
+	if o.rate < 0 {
+		return fmt.Errorf("the rate can't be negative, got %v", o.rate)
+	}
+	var err error
+	if since != "" {
+		if o.since, err = time.ParseInLocation(dateLayout, since, time.Local); err != nil {
+			return fmt.Errorf("invalid --since date %q, expected YYYY-MM-DD", since)
+		}
+	}
+	if until != "" {
+		if o.until, err = time.ParseInLocation(dateLayout, until, time.Local); err != nil {
+			return fmt.Errorf("invalid --until date %q, expected YYYY-MM-DD", until)
+		}
+		o.until = o.until.AddDate(0, 0, 1)
+	}
+	return nil
+}
+
+// report totals the matching entries by group, periods oldest first and models most expensive first
+func (o usageOptions) report(entries []UsageEntry) ([]usageRow, usageRow) {
+	total := usageRow{Group: "total"}
+	byGroup := make(map[string]*usageRow)
+	var rows []*usageRow
+	for _, e := range entries {
+		switch {
+		case !o.since.IsZero() && e.Time.Before(o.since):
+		case !o.until.IsZero() && !e.Time.Before(o.until):
+		case o.profile != "" && e.Profile != o.profile:
+		case o.model != "" && e.Model != o.model:
+		default:
+			group := o.group(e)
+			row, ok := byGroup[group]
+			if !ok {
+				row = &usageRow{Group: group}
+				byGroup[group] = row
+				rows = append(rows, row)
+			}
+			for _, r := range []*usageRow{row, &total} {
+				r.Answers++
+				r.Words += e.Words.Total
+				r.Coins += e.Price.Total
+				r.Cost = r.Coins * o.rate
+			}
+		}
+	}
+
+	if o.by == ByModel {
+		slices.SortStableFunc(rows, func(a, b *usageRow) int { return cmp.Compare(b.Coins, a.Coins) })
+	} else {
+		slices.SortStableFunc(rows, func(a, b *usageRow) int { return cmp.Compare(a.Group, b.Group) })
+	}
+	report := make([]usageRow, len(rows))
+	for i, r := range rows {
+		report[i] = *r
+	}
+	return report, total
+}
+
+// group is the day, ISO week, month or model an entry is counted in
+func (o usageOptions) group(e UsageEntry) string {
+	local := e.Time.Local()
+	switch o.by {
+	case ByWeek:
+		year, week := local.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case ByMonth:
+		return local.Format("2006-01")
+	case ByModel:
+		return e.Model
+	default:
+		return local.Format(dateLayout)
+	}
+}
+
+// costHeader names the cost column, empty without a rate
+func (o usageOptions) costHeader() string {
+	if o.rate == 0 {
+		return ""
+	}
+	if o.currency == "" {
+		return "cost"
+	}
+	return "cost " + o.currency
+}
+
+func (o usageOptions) writeTable(stdout io.Writer, rows []usageRow, total usageRow) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(stdout, "no usage recorded")
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	header := strings.ToUpper(o.by) + "\tANSWERS\tWORDS\tCOINS"
+	if cost := o.costHeader(); cost != "" {
+		header += "\t" + strings.ToUpper(cost)
+	}
+	fmt.Fprintln(w, header)
+	line := func(group string, r usageRow) {
+		fmt.Fprintf(w, "%s\t%d\t%.0f\t%.2f", group, r.Answers, r.Words, r.Coins)
+		if o.rate != 0 {
+			fmt.Fprintf(w, "\t%.2f", r.Cost)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, r := range rows {
+		line(r.Group, r)
+	}
+	fmt.Fprintln(w)
+	line("TOTAL", total)
+	return w.Flush()
+}
+
+func writeUsageJSON(stdout io.Writer, rows []usageRow, total usageRow) error {
+	if rows == nil {
+		rows = []usageRow{}
+	}
+	data, err := json.MarshalIndent(struct {
+		Rows  []usageRow `json:"rows"`
+		Total usageRow   `json:"total"`
+	}{rows, total}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing usage: %w", err)
+	}
+	_, err = stdout.Write(append(data, '\n'))
+	return err
+}
+
+// writeCSV writes a row per group, without the total so the rows can be summed in a spreadsheet
+func (o usageOptions) writeCSV(stdout io.Writer, rows []usageRow) error {
+	w := csv.NewWriter(stdout)
+	header := []string{o.by, "answers", "words", "coins"}
+	if cost := o.costHeader(); cost != "" {
+		header = append(header, strings.ReplaceAll(cost, " ", "_"))
+	}
+	w.Write(header)
+	for _, r := range rows {
+		record := []string{r.Group, strconv.Itoa(r.Answers), strconv.FormatFloat(r.Words, 'f', 0, 64), strconv.FormatFloat(r.Coins, 'f', 4, 64)}
+		if o.rate != 0 {
+			record = append(record, strconv.FormatFloat(r.Cost, 'f', 4, 64))
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return w.Error()
+}
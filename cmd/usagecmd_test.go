@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func usageEntry(day string, model string, coins float64, profile string) UsageEntry {
+	when, _ := time.ParseInLocation(dateLayout, day, time.Local)
+	return UsageEntry{Time: when.Add(12 * time.Hour), Model: model, Price: prompt.OverallPrice{Total: coins},
+		Words: prompt.OverallPrice{Total: 100}, Source: SourceChat, Profile: profile}
+}
+
+func TestRunUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := RecordUsage([]UsageEntry{
+		usageEntry("2025-03-03", "openai/gpt-4.1", 2, ""),
+		usageEntry("2025-03-03", "anthropic/claude-sonnet-4", 5, "work"),
+		usageEntry("2025-03-04", "openai/gpt-4.1", 1.5, "work"),
+		usageEntry("2025-03-12", "openai/gpt-4.1", 3, ""),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runUsage([]string{"--by", "week", "--rate", "0.01", "--currency", "USD"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasPrefix(lines[0], "WEEK") || !strings.Contains(lines[0], "COST USD") {
+		t.Errorf("Expected a header with the cost, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "2025-W10") || !strings.Contains(lines[1], "8.50") || !strings.Contains(lines[1], "0.09") {
+		t.Errorf("Expected the first week's coins and cost, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "TOTAL") || !strings.Contains(lines[4], "11.50") {
+		t.Errorf("Expected the total last, got %q", lines[4])
+	}
+
+	out.Reset()
+	if err := runUsage([]string{"--by", "model", "--profile", "work", "--since", "2025-03-03", "--until", "2025-03-04", "-o", "csv"}, IO{Stdout: &out}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "model,answers,words,coins\nanthropic/claude-sonnet-4,1,100,5.0000\nopenai/gpt-4.1,1,100,1.5000\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	if err := runUsage([]string{"--since", "March"}, IO{Stdout: &out}); err == nil {
+		t.Error("Expected an invalid date to fail")
+	}
+}
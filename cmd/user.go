@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// userApi follows the base url of the active profile
+var userApi = DefaultBaseURL + "/v0/user"
+
+var userClient = http.Client{
+	Timeout: time.Second * 20,
+}
+
+// User is the account the API key belongs to
+type User struct {
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Coins     float64 `json:"coins"`
+	Plan      string  `json:"plan"`
+}
+
+type userResponse struct {
+	Data    User `json:"data"`
+	Success bool `json:"success"`
+}
+
+// Name is the user's full name
+func (u User) Name() string {
+	switch {
+	case u.FirstName == "":
+		return u.LastName
+	case u.LastName == "":
+		return u.FirstName
+	}
+	return u.FirstName + " " + u.LastName
+}
+
+// GetUser asks the API for the account of apiKey, with its coin balance
+func GetUser(apiKey string) (User, error) {
+	req, _ := http.NewRequest("GET", userApi, nil)
+	req.Header = http.Header{
+		"Authorization": []string{"Bearer " + apiKey},
+		"Accept":        []string{"application/json"},
+	}
+	resp, err := userClient.Do(req)
+	if err != nil {
+		return User{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return User{}, fmt.Errorf("the API key was refused: %s", resp.Status)
+	}
+	if resp.StatusCode > 299 || resp.StatusCode < 200 {
+		return User{}, fmt.Errorf("request failed. Error: %s", resp.Status)
+	}
+	bodyText, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return User{}, fmt.Errorf("unable to read body. Error: %w", err)
+	}
+	var r userResponse
+	if err := json.Unmarshal(bodyText, &r); err != nil {
+		return User{}, fmt.Errorf("request failed. Error: %w", err)
+	}
+	if !r.Success {
+		return User{}, fmt.Errorf("request failed. Error: the API did not return the account")
+	}
+	return r.Data, nil
+}
+
+// LowBalance reports whether coins are below the low_balance setting, an empty account always is
+func (c *ConfigFile) LowBalance(coins float64) bool {
+	return coins <= 0 || (c.BalanceWarning > 0 && coins < c.BalanceWarning)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// WhoamiCommand shows the account of the API key
+var WhoamiCommand = Command{Name: "whoami", Summary: "Show the account and its coin balance", Run: runWhoami}
+
+// BalanceCommand prints the coins left, for scripts and status bars
+var BalanceCommand = Command{Name: "balance", Summary: "Print the coins left on the account", Run: runBalance}
+
+const whoamiUsage = `whoami [flags]
+
+Shows the account the API key belongs to, its plan and the coins left.`
+
+const balanceUsage = `balance [flags]
+
+Prints the coins left on the account. Below low_balance a warning goes to stderr.`
+
+func runWhoami(args []string, stdio IO) error {
+	flags := NewFlagSet("whoami", whoamiUsage, stdio)
+	profile := flags.String("profile", "", "Config profile to use, defaults to $"+ProfileEnv)
+	outputFormat := flags.StringP("output", "o", OutputText, "Output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *outputFormat != OutputText && *outputFormat != OutputJSON {
+		return fmt.Errorf("unknown output format %q, expected text or json", *outputFormat)
+	}
+	config, user, err := loadUser(*profile, stdio)
+	if err != nil {
+		return err
+	}
+
+	if *outputFormat == OutputJSON {
+		data, err := json.MarshalIndent(struct {
+			User
+			Profile   string `json:"profile,omitempty"`
+			KeySource string `json:"key_source"`
+		}{user, config.ActiveProfile, config.KeySource()}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error serializing account: %w", err)
+		}
+		_, err = stdio.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	w := tabwriter.NewWriter(stdio.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "name\t%s\n", user.Name())
+	if user.Plan != "" {
+		fmt.Fprintf(w, "plan\t%s\n", user.Plan)
+	}
+	fmt.Fprintf(w, "coins\t%s\n", strconv.FormatFloat(user.Coins, 'f', 2, 64))
+	if config.ActiveProfile != "" {
+		fmt.Fprintf(w, "profile\t%s\n", config.ActiveProfile)
+	}
+	fmt.Fprintf(w, "key\t%s (%s)\n", mask(config.Key), config.KeySource())
+	fmt.Fprintf(w, "api\t%s\n", config.APIBase())
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	warnLowBalance(config, user, stdio.Stderr)
+	return nil
+}
+
+func runBalance(args []string, stdio IO) error {
+	flags := NewFlagSet("balance", balanceUsage, stdio)
+	profile := flags.String("profile", "", "Config profile to use, defaults to $"+ProfileEnv)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	config, user, err := loadUser(*profile, stdio)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(stdio.Stdout, strconv.FormatFloat(user.Coins, 'f', 2, 64)); err != nil {
+		return err
+	}
+	warnLowBalance(config, user, stdio.Stderr)
+	return nil
+}
+
+// loadUser loads the config of the profile and asks the API for its account
+func loadUser(profile string, stdio IO) (*ConfigFile, User, error) {
+	config, err := loadWithKey(profile, stdio.Stderr)
+	if err != nil {
+		return nil, User{}, err
+	}
+	if config.Key == "" {
+		return nil, User{}, errors.New("no API key configured, set one with straico-cli config set key")
+	}
+	user, err := GetUser(config.Key)
+	if err != nil {
+		return nil, User{}, fmt.Errorf("unable to get the account: %w", err)
+	}
+	return config, user, nil
+}
+
+// warnLowBalance names the low_balance threshold only when one is set, an empty account is out of coins
+func warnLowBalance(config *ConfigFile, user User, stderr io.Writer) {
+	switch {
+	case !config.LowBalance(user.Coins):
+	case user.Coins <= 0:
+		_, _ = io.WriteString(stderr, "the account is out of coins\n")
+	default:
+		_, _ = io.WriteString(stderr, "low balance: "+strconv.FormatFloat(user.Coins, 'f', 2, 64)+
+			" coins left, below "+formatCoins(config.BalanceWarning)+"\n")
+	}
+}
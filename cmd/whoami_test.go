@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// userServer serves an account with coins as the API of a fresh config
+func userServer(t *testing.T, coins float64) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/user" || r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data":    map[string]any{"first_name": "Ada", "last_name": "Lovelace", "coins": coins, "plan": "Pro"},
+			"success": true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnv, "")
+	t.Setenv(KeyEnv, "test-key")
+	t.Setenv("STRAICO_BASE_URL", server.URL)
+}
+
+func TestRunWhoami(t *testing.T) {
+	userServer(t, 1234.5)
+
+	var out, stderr bytes.Buffer
+	if err := runWhoami(nil, IO{Stdout: &out, Stderr: &stderr}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{"Ada Lovelace", "Pro", "1234.50", "****-key (env " + KeyEnv + ")"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, out.String())
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warning without low_balance, got %q", stderr.String())
+	}
+
+	out.Reset()
+	if err := runWhoami([]string{"-o", "json"}, IO{Stdout: &out, Stderr: &stderr}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var account struct {
+		Coins     float64 `json:"coins"`
+		KeySource string  `json:"key_source"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &account); err != nil || account.Coins != 1234.5 {
+		t.Errorf("Expected the account as json, got %s", out.String())
+	}
+}
+
+func TestRunBalance(t *testing.T) {
+	userServer(t, 42)
+	t.Setenv("STRAICO_LOW_BALANCE", "100")
+
+	var out, stderr bytes.Buffer
+	if err := runBalance(nil, IO{Stdout: &out, Stderr: &stderr}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if out.String() != "42.00\n" {
+		t.Errorf("Expected only the coins, got %q", out.String())
+	}
+	if !strings.Contains(stderr.String(), "low balance: 42.00 coins left, below 100") {
+		t.Errorf("Expected a low balance warning, got %q", stderr.String())
+	}
+
+	t.Setenv(KeyEnv, "wrong-key")
+	if err := runBalance(nil, IO{Stdout: &out, Stderr: &stderr}); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("Expected a refused key, got %v", err)
+	}
+}
+
+func TestWarnLowBalance(t *testing.T) {
+	for _, test := range []struct {
+		threshold, coins float64
+		want             string
+	}{
+		{0, 10, ""},
+		{0, 0, "the account is out of coins\n"},
+		{100, 0, "the account is out of coins\n"},
+		{100, 42, "low balance: 42.00 coins left, below 100\n"},
+	} {
+		var stderr bytes.Buffer
+		warnLowBalance(&ConfigFile{BalanceWarning: test.threshold}, User{Coins: test.coins}, &stderr)
+		if stderr.String() != test.want {
+			t.Errorf("Expected %q with %v coins and low_balance %v, got %q", test.want, test.coins, test.threshold, stderr.String())
+		}
+	}
+}
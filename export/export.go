@@ -0,0 +1,194 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Export formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Transcript is a normalized conversation, also written as is for the json format.
+type Transcript struct {
+	Title    string    `json:"title"`
+	Persona  string    `json:"persona,omitempty"`
+	Exported time.Time `json:"exported"`
+	Coins    float64   `json:"coins"`
+	Entries  []Entry   `json:"messages"`
+}
+
+// Entry is a single message of a Transcript
+type Entry struct {
+	Role         string    `json:"role"`
+	Content      string    `json:"content"`
+	Model        string    `json:"model,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	Coins        float64   `json:"coins,omitempty"`
+	Tokens       int64     `json:"tokens,omitempty"`
+	FinishReason string    `json:"finish_reason,omitempty"`
+	Error        bool      `json:"error,omitempty"`
+}
+
+// ParseFormat accepts a format name or its file extension
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected markdown, html or json", format)
+}
+
+// Extension is the file extension, without the dot, used for format
+func Extension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// Write renders t in format to w
+func Write(w io.Writer, format string, t Transcript) error {
+	switch format {
+	case FormatMarkdown:
+		return Markdown(w, t)
+	case FormatHTML:
+		return HTML(w, t)
+	case FormatJSON:
+		return JSON(w, t)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// JSON writes the transcript as indented json
+func JSON(w io.Writer, t Transcript) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing transcript: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Markdown writes the transcript with a heading per message
+func Markdown(w io.Writer, t Transcript) error {
+	var b strings.Builder
+	b.WriteString("# " + t.Title + "\n\n")
+	b.WriteString("_" + summary(t) + "_\n")
+	for _, e := range t.Entries {
+		b.WriteString("\n### " + heading(e) + "\n\n")
+		if e.Error {
+			b.WriteString("> " + strings.ReplaceAll(e.Content, "\n", "\n> ") + "\n")
+		} else {
+			b.WriteString(strings.TrimRight(e.Content, "\n") + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// HTML writes a self-contained page, answers are converted from markdown
+func HTML(w io.Writer, t Transcript) error {
+	type htmlEntry struct {
+		Class   string
+		Heading string
+		Body    template.HTML
+	}
+	entries := make([]htmlEntry, len(t.Entries))
+	for i, e := range t.Entries {
+		entry := htmlEntry{Class: e.Role, Heading: heading(e)}
+		if e.Error {
+			entry.Class = "error"
+		}
+		if e.Role == "assistant" && !e.Error {
+			var body bytes.Buffer
+			// goldmark leaves out raw html unless told otherwise, so answers can't inject markup
+			if err := markdownConverter.Convert([]byte(e.Content), &body); err != nil {
+				return fmt.Errorf("error converting markdown: %w", err)
+			}
+			entry.Body = template.HTML(body.String())
+		} else {
+			entry.Body = template.HTML("<p class=\"plain\">" + template.HTMLEscapeString(e.Content) + "</p>")
+		}
+		entries[i] = entry
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Title   string
+		Summary string
+		Entries []htmlEntry
+	}{t.Title, summary(t), entries})
+}
+
+var markdownConverter = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+func heading(e Entry) string {
+	switch {
+	case e.Error:
+		return "Error"
+	case e.Role == "user":
+		return "You"
+	}
+	h := "LLM"
+	if e.Model != "" {
+		h = e.Model
+	}
+	return h + " · " + strconv.FormatFloat(e.Coins, 'f', 2, 64) + " coins"
+}
+
+func summary(t Transcript) string {
+	s := "Exported " + t.Exported.Format("2006-01-02 15:04") + " · " + strconv.FormatFloat(t.Coins, 'f', 2, 64) + " coins"
+	if t.Persona != "" {
+		s += " · persona " + t.Persona
+	}
+	return s
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+header p { color: #656d76; }
+section { border-left: 4px solid #d0d7de; padding: 0.25rem 1rem; margin: 1.5rem 0; }
+section.user { border-color: #8250df; }
+section.assistant { border-color: #1a7f37; }
+section.error { border-color: #cf222e; }
+h3 { font-size: 0.9rem; color: #656d76; margin: 0.5rem 0; }
+.plain { white-space: pre-wrap; }
+pre { background: #f6f8fa; padding: 0.75rem; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #d0d7de; padding: 0.25rem 0.5rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{.Summary}}</p>
+</header>
+{{range .Entries}}<section class="{{.Class}}">
+<h3>{{.Heading}}</h3>
+{{.Body}}
+</section>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTranscript() Transcript {
+	return Transcript{
+		Title:    "Straico conversation 1",
+		Exported: time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC),
+		Coins:    1.25,
+		Entries: []Entry{
+			{Role: "user", Content: "Show me <b>html</b>"},
+			{Role: "assistant", Content: "Sure:\n\n```go\nfmt.Println(1)\n```\n\n<script>alert(1)</script>", Model: "test-model", Coins: 1.25},
+			{Role: "assistant", Content: "request failed", Error: true},
+		},
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	if err := Markdown(&out, testTranscript()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"# Straico conversation 1",
+		"_Exported 2025-01-02 03:04 · 1.25 coins_",
+		"### You\n\nShow me <b>html</b>",
+		"### test-model · 1.25 coins\n\nSure:",
+		"### Error\n\n> request failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := HTML(&out, testTranscript()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	page := out.String()
+
+	if !strings.Contains(page, "Show me &lt;b&gt;html&lt;/b&gt;") {
+		t.Error("Expected user content to be escaped")
+	}
+	if strings.Contains(page, "<script>") {
+		t.Error("Expected raw html in answers to be dropped")
+	}
+	if !strings.Contains(page, `<code class="language-go">`) {
+		t.Error("Expected fenced code to be converted")
+	}
+	if !strings.Contains(page, "<style>") || strings.Contains(page, "<link") {
+		t.Error("Expected a self-contained page")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := JSON(&out, testTranscript()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var loaded Transcript
+	if err := json.Unmarshal(out.Bytes(), &loaded); err != nil {
+		t.Fatalf("Failed to unmarshal transcript: %v", err)
+	}
+	if len(loaded.Entries) != 3 || loaded.Entries[1].Model != "test-model" || loaded.Entries[1].Coins != 1.25 {
+		t.Errorf("Unexpected transcript %+v", loaded)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]string{"md": FormatMarkdown, "Markdown": FormatMarkdown, "htm": FormatHTML, "json": FormatJSON} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q): Expected %q, got %q, %v", in, want, got, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -4,32 +4,58 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package main
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/cmd/history"
 	"github.com/tyler71/straico-cli/m/v0/tui"
-	"log"
-	"os"
-	"strconv"
 )
 
-// Build Args
-var promptUrlPrefix = "https://api.straico.com/v1/prompt/completion"
+// commands lists the subcommands, the first one runs when none is named
+var commands = []cmd.Command{
+	{Name: "chat", Summary: "Chat in the terminal UI", Run: runChat},
+	cmd.AskCommand,
+	cmd.ModelsCommand,
+	cmd.UsageCommand,
+	cmd.WhoamiCommand,
+	cmd.BalanceCommand,
+	cmd.ConfigCommand,
+	history.ExportCommand,
+	history.SearchCommand,
+}
 
 func main() {
-	configFile := cmd.Init()
-	configFile.Prompt.UrlPrefix = promptUrlPrefix
+	stdio := cmd.IO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := cmd.Execute(commands, os.Args[1:], stdio); err != nil {
+		os.Stderr.Write([]byte(err.Error() + "\n"))
+		os.Exit(1)
+	}
+}
+
+// runChat starts the TUI, unless the flags ask for something else such as a single prompt
+func runChat(args []string, stdio cmd.IO) error {
+	configFile, handled, err := cmd.ParseChat(args, stdio)
+	if err != nil || handled {
+		return err
+	}
 
 	state := tui.State{}
 	p := tea.NewProgram(
 		tui.NewModel(configFile, &state),
-		tea.WithAltScreen(),       // Use alternate screen buffer
-		tea.WithMouseCellMotion(), // Capture mouse events
+		tea.WithAltScreen(),        // Use alternate screen buffer
+		tea.WithMouseCellMotion(),  // Capture mouse events
+		tea.WithOutput(tui.Output), // Shared with the clipboard's escape sequences
 	)
 
 	if _, err := p.Run(); err != nil {
-		log.Fatalln("Error running program:", err)
+		return fmt.Errorf("error running program: %w", err)
 	}
+	state.Conversations.Close()
 	if state.CoinUsage > 0 {
-		os.Stderr.Write([]byte(strconv.FormatFloat(state.CoinUsage, 'f', 2, 64) + " coins used during session.\n"))
+		stdio.Stderr.Write([]byte(strconv.FormatFloat(state.CoinUsage, 'f', 2, 64) + " coins used during session.\n"))
 	}
+	return nil
 }
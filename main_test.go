@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
 )
 
 // This is a simple test to ensure the main package can be imported
@@ -9,3 +13,15 @@ func TestMainPackage(t *testing.T) {
 	// This test doesn't actually test any functionality,
 	// but ensures the main package can be compiled and imported
 }
+
+func TestCommandsHelp(t *testing.T) {
+	for _, c := range commands {
+		var out bytes.Buffer
+		if err := cmd.Execute(commands, []string{"help", c.Name}, cmd.IO{Stdout: &out}); err != nil {
+			t.Errorf("Expected help for %s, got %v", c.Name, err)
+		}
+		if !strings.Contains(out.String(), "usage: straico-cli ") {
+			t.Errorf("Expected the usage of %s, got %q", c.Name, out.String())
+		}
+	}
+}
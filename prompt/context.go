@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// DefaultContextWords is the context budget used when the model's limits are unknown
+const DefaultContextWords = 2000
+
+// WordsPerToken converts the model's token limits to words, English averages about 0.75 words a token
+const WordsPerToken = 0.75
+
+const contextInstruction = "Answer the question using the context below. Do not mention the context"
+
+// Turn is a single earlier message sent along as context
+type Turn struct {
+	Role    string
+	Content string
+}
+
+// BuildMessage assembles the message sent to the api from earlier turns and the new question.
+// Turns are dropped whole, oldest first, until the rest fits the model's context window.
+func (p Prompt) BuildMessage(text string, context []Turn) string {
+	context = fitContext(context, p.contextBudget(text))
+	if len(context) == 0 {
+		if p.System != "" {
+			return p.System + "\n\n" + text
+		}
+		return text
+	}
+
+	var b strings.Builder
+	if p.System != "" {
+		b.WriteString(p.System + "\n\n")
+	}
+	b.WriteString(contextInstruction + "\n")
+	for _, t := range context {
+		if t.Role == RoleUser {
+			b.WriteString("User: ")
+		} else {
+			b.WriteString("Assistant: ")
+		}
+		b.WriteString(t.Content + "\n")
+	}
+	b.WriteString("Question:" + text + "\nAnswer:")
+	return b.String()
+}
+
+// contextBudget is how many words of context fit next to the question and the model's answer
+func (p Prompt) contextBudget(text string) int {
+	if p.WordLimit <= 0 {
+		return DefaultContextWords
+	}
+	budget := int(p.WordLimit) - OutputWords(p.MaxOutput) - countWords(text) - countWords(contextInstruction) - countWords(p.System)
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// OutputWords is how many words an answer of tokens may take, rounded up
+func OutputWords(tokens int64) int {
+	return int(math.Ceil(float64(tokens) * WordsPerToken))
+}
+
+// fitContext keeps the most recent turns that fit within budget words
+func fitContext(context []Turn, budget int) []Turn {
+	start := len(context)
+	used := 0
+	for start > 0 {
+		words := countWords(context[start-1].Content)
+		if used+words > budget {
+			break
+		}
+		used += words
+		start--
+	}
+	// An answer whose question was dropped has nothing to refer to
+	for start < len(context) && context[start].Role != RoleUser {
+		start++
+	}
+	return context[start:]
+}
+
+func countWords(s string) int {
+	return len(strings.Fields(s))
+}
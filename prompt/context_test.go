@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageWithoutContext(t *testing.T) {
+	p := Prompt{}
+
+	if got := p.BuildMessage("Hello", nil); got != "Hello" {
+		t.Errorf("Expected bare question, got %q", got)
+	}
+}
+
+func TestBuildMessageIncludesAnswers(t *testing.T) {
+	p := Prompt{}
+	context := []Turn{
+		{Role: RoleUser, Content: "Write a regex for dates"},
+		{Role: RoleAssistant, Content: `\d{4}-\d{2}-\d{2}`},
+	}
+
+	got := p.BuildMessage("now rewrite that in Go", context)
+
+	for _, want := range []string{"User: Write a regex for dates", `Assistant: \d{4}-\d{2}-\d{2}`, "Question:now rewrite that in Go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected message to contain %q, got %q", want, got)
+		}
+	}
+}
+
+func TestBuildMessageDropsOldestTurns(t *testing.T) {
+	// 13 words go to the question and instruction, leaving room for two of the 5 word turns
+	p := Prompt{WordLimit: 30, MaxOutput: 5}
+	context := []Turn{
+		{Role: RoleUser, Content: "oldest question one two three"},
+		{Role: RoleAssistant, Content: "oldest answer one two three"},
+		{Role: RoleUser, Content: "newer question one two three"},
+		{Role: RoleAssistant, Content: "newer answer one two three"},
+	}
+
+	got := p.BuildMessage("q", context)
+
+	if strings.Contains(got, "oldest") {
+		t.Errorf("Expected oldest turns to be dropped, got %q", got)
+	}
+	if !strings.Contains(got, "User: newer question") || !strings.Contains(got, "Assistant: newer answer") {
+		t.Errorf("Expected newer turns to be kept, got %q", got)
+	}
+}
+
+func TestFitContextDropsOrphanedAnswer(t *testing.T) {
+	context := []Turn{
+		{Role: RoleUser, Content: "one two three"},
+		{Role: RoleAssistant, Content: "one two"},
+		{Role: RoleUser, Content: "one"},
+		{Role: RoleAssistant, Content: "one"},
+	}
+
+	got := fitContext(context, 4)
+
+	if len(got) != 2 || got[0].Role != RoleUser {
+		t.Errorf("Expected the last question and answer, got %+v", got)
+	}
+}
+
+func TestBuildMessageWithSystem(t *testing.T) {
+	p := Prompt{System: "You are a pirate."}
+
+	if got := p.BuildMessage("Hello", nil); got != "You are a pirate.\n\nHello" {
+		t.Errorf("Expected system prompt before the question, got %q", got)
+	}
+
+	got := p.BuildMessage("Hello", []Turn{{Role: RoleUser, Content: "Hi"}})
+	if !strings.HasPrefix(got, "You are a pirate.\n\n"+contextInstruction) {
+		t.Errorf("Expected system prompt before the context, got %q", got)
+	}
+}
+
+func TestContextBudgetConvertsOutputTokens(t *testing.T) {
+	// 100 output tokens take about 75 words
+	p := Prompt{WordLimit: 1000, MaxOutput: 100}
+
+	got := p.contextBudget("q")
+
+	if want := 1000 - 75 - 1 - countWords(contextInstruction); got != want {
+		t.Errorf("Expected a budget of %d words, got %d", want, got)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -17,6 +16,12 @@ type Prompt struct {
 	YoutubeUrls []string `json:"youtube_urls,omitempty"`
 	MaxToken    int      `json:"max_tokens,omitempty"`
 	UrlPrefix   string
+	// System is sent ahead of the context and question
+	System string `json:"-"`
+	// WordLimit and MaxOutput of the selected model size the context window,
+	// WordLimit counts words while MaxOutput counts tokens
+	WordLimit int64 `json:"-"`
+	MaxOutput int64 `json:"-"`
 }
 
 const MaxContextLength = 25
@@ -26,21 +31,8 @@ var httpClient = http.Client{
 }
 
 // Request main entrypoint, This requests from the api and returns the response.
-func (p Prompt) Request(key string, text string, context []string) (response StraicoResponse, err error) {
-	promptHistory := strings.Join(context, "\n")
-	contextLength := len(promptHistory)
-	if contextLength < MaxContextLength {
-		contextLength = MaxContextLength
-	}
-	if len(context) > 1 {
-		if contextLength > 1000 {
-			p.Message = "Answer the question using the context below. Do not mention the context\n" + promptHistory[contextLength-1000:] + "\nQuestion:" + text + "\nAnswer:"
-		} else {
-			p.Message = "Answer the question using the context below. Do not mention the context\n" + promptHistory + "\nQuestion:" + text + "\nAnswer:"
-		}
-	} else {
-		p.Message = text
-	}
+func (p Prompt) Request(key string, text string, context []Turn) (response StraicoResponse, err error) {
+	p.Message = p.BuildMessage(text, context)
 	jsonAbc, _ := json.Marshal(p)
 	client := &httpClient
 	req, _ := http.NewRequest("POST", p.UrlPrefix, bytes.NewBuffer(jsonAbc))
@@ -140,7 +140,10 @@ func TestPromptRequest(t *testing.T) {
 			"Content-Type":  []string{"application/json"},
 			"Accept":        []string{"application/json"},
 		}
-		resp, _ := client.Do(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			return StraicoResponse{}, err
+		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode < 200 || resp.StatusCode > 299 {
@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"sort"
+)
+
+// Result is the envelope written by --output json and jsonl.
+// Field names are part of the CLI's output contract, add fields but do not rename them.
+type Result struct {
+	Success bool          `json:"success"`
+	Error   string        `json:"error,omitempty"`
+	Models  []ModelResult `json:"models"`
+	Price   OverallPrice  `json:"price"`
+	Words   OverallPrice  `json:"words"`
+}
+
+// ModelResult is a single model's answer within a Result.
+type ModelResult struct {
+	Model        string       `json:"model"`
+	Content      string       `json:"content"`
+	FinishReason string       `json:"finish_reason"`
+	Price        OverallPrice `json:"price"`
+	Words        OverallPrice `json:"words"`
+	Usage        Usage        `json:"usage"`
+}
+
+// NewResult flattens a response into a Result.
+// Models are listed in the order they were requested, followed by any others the API returned.
+func NewResult(r StraicoResponse, models []string) Result {
+	result := Result{
+		Success: r.Success,
+		Models:  make([]ModelResult, 0, len(r.Data.Completions)),
+		Price:   r.Data.OverallPrice,
+		Words:   r.Data.OverallWords,
+	}
+
+	seen := make(map[string]bool, len(models))
+	order := make([]string, 0, len(r.Data.Completions))
+	for _, m := range models {
+		if _, ok := r.Data.Completions[m]; ok && !seen[m] {
+			seen[m] = true
+			order = append(order, m)
+		}
+	}
+	var extra []string
+	for m := range r.Data.Completions {
+		if !seen[m] {
+			extra = append(extra, m)
+		}
+	}
+	sort.Strings(extra)
+	order = append(order, extra...)
+
+	for _, m := range order {
+		llm := r.Data.Completions[m]
+		modelResult := ModelResult{
+			Model: m,
+			Price: llm.Price,
+			Words: llm.Words,
+			Usage: llm.Completion.Usage,
+		}
+		if len(llm.Completion.Choices) > 0 {
+			modelResult.Content = llm.Completion.Choices[0].Message.Content
+			modelResult.FinishReason = llm.Completion.Choices[0].FinishReason
+		}
+		result.Models = append(result.Models, modelResult)
+	}
+	return result
+}
+
+// ErrorResult is the envelope written when a request fails.
+func ErrorResult(err error) Result {
+	return Result{Error: err.Error(), Models: []ModelResult{}}
+}
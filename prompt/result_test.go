@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewResult(t *testing.T) {
+	response := StraicoResponse{
+		Success: true,
+		Data: Data{
+			OverallPrice: OverallPrice{Total: 3},
+			OverallWords: OverallPrice{Total: 30},
+			Completions: map[string]LLMModel{
+				"model-b": {
+					Completion: LLMCompletion{Choices: []Choice{{Message: Message{Content: "B"}, FinishReason: "stop"}}},
+					Price:      OverallPrice{Total: 2},
+				},
+				"model-a": {
+					Completion: LLMCompletion{Choices: []Choice{{Message: Message{Content: "A"}, FinishReason: "length"}}},
+					Price:      OverallPrice{Total: 1},
+				},
+			},
+		},
+	}
+
+	result := NewResult(response, []string{"model-b", "model-a"})
+
+	if !result.Success {
+		t.Error("Expected Success to be true")
+	}
+	if result.Price.Total != 3 || result.Words.Total != 30 {
+		t.Errorf("Expected overall price 3 and words 30, got %f and %f", result.Price.Total, result.Words.Total)
+	}
+	if len(result.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(result.Models))
+	}
+	if result.Models[0].Model != "model-b" || result.Models[1].Model != "model-a" {
+		t.Errorf("Expected requested model order, got %q, %q", result.Models[0].Model, result.Models[1].Model)
+	}
+	if result.Models[1].Content != "A" || result.Models[1].FinishReason != "length" || result.Models[1].Price.Total != 1 {
+		t.Errorf("Unexpected model result %+v", result.Models[1])
+	}
+}
+
+func TestErrorResult(t *testing.T) {
+	result := ErrorResult(errors.New("request failed"))
+
+	if result.Success {
+		t.Error("Expected Success to be false")
+	}
+	if result.Error != "request failed" {
+		t.Errorf("Expected Error 'request failed', got %q", result.Error)
+	}
+	if result.Models == nil {
+		t.Error("Expected non-nil Models")
+	}
+}
@@ -0,0 +1,116 @@
+// Package statefile writes the config and conversation files without corrupting them,
+// and keeps several straico-cli processes from writing them at the same time.
+package statefile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Backups is the number of previous versions kept as path.bak.1 (newest) to path.bak.N
+const Backups = 3
+
+// LockTimeout is how long Lock waits for another process to release the file
+var LockTimeout = 5 * time.Second
+
+// Write replaces path with data through a temporary file, so a crash leaves either the old or the new
+// version. The previous version is kept as a backup.
+func Write(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	// Only does something when the rename did not happen
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to set file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to flush temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+
+	if err := rotateBackups(path, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("unable to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// BackupPath is the name of the nth backup of path, 1 being the most recent
+func BackupPath(path string, n int) string {
+	return path + ".bak." + strconv.Itoa(n)
+}
+
+// rotateBackups shifts the backups by one and copies the current file to the first, with mode perm
+func rotateBackups(path string, perm os.FileMode) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	for n := Backups - 1; n >= 1; n-- {
+		if err := os.Rename(BackupPath(path, n), BackupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to rotate backups: %w", err)
+		}
+	}
+	if err := copyFile(path, BackupPath(path, 1), perm); err != nil {
+		return fmt.Errorf("unable to back up %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func copyFile(from, to string, perm os.FileMode) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	// An existing backup keeps its mode otherwise
+	if err := dst.Chmod(perm); err != nil {
+		dst.Close()
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Lock takes an advisory lock on path.lock, waiting up to LockTimeout for other processes.
+// Call the returned function to release it.
+func Lock(path string) (func(), error) {
+	lock := flock.New(path + ".lock")
+	ctx, cancel := context.WithTimeout(context.Background(), LockTimeout)
+	defer cancel()
+	locked, err := lock.TryLockContext(ctx, 50*time.Millisecond)
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("unable to lock %s: %w", filepath.Base(path), err)
+	}
+	if !locked {
+		return nil, fmt.Errorf("%s is locked by another straico-cli", filepath.Base(path))
+	}
+	return func() { lock.Unlock() }, nil
+}
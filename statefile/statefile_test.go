@@ -0,0 +1,62 @@
+package statefile
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	for i := 1; i <= Backups+2; i++ {
+		if err := Write(path, []byte(strconv.Itoa(i)), 0600); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != strconv.Itoa(Backups+2) {
+		t.Errorf("Expected latest version, got %q, %v", data, err)
+	}
+	for n := 1; n <= Backups; n++ {
+		data, err := os.ReadFile(BackupPath(path, n))
+		if want := strconv.Itoa(Backups + 2 - n); err != nil || string(data) != want {
+			t.Errorf("Backup %d: Expected %q, got %q, %v", n, want, data, err)
+		}
+	}
+	if _, err := os.Stat(BackupPath(path, Backups+1)); !os.IsNotExist(err) {
+		t.Errorf("Expected only %d backups", Backups)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v, %v", info.Mode().Perm(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != Backups+1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	LockTimeout = 100 * time.Millisecond
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Expected lock, got %v", err)
+	}
+	if _, err := Lock(path); err == nil {
+		t.Error("Expected the second lock to time out")
+	}
+	unlock()
+
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Expected lock after release, got %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// browser is the overlay listing every saved conversation
+type browser struct {
+	open   bool
+	cursor int
+	items  []*Conversation
+	// renaming edits the name of the conversation under the cursor
+	renaming bool
+	input    textinput.Model
+	// deleting waits for the delete to be confirmed
+	deleting bool
+}
+
+// openBrowser lists conversations by last activity, with the cursor on the current one
+func (s *State) openBrowser() {
+	s.browser = browser{open: true, items: s.Conversations.ByActivity()}
+	for i, conv := range s.browser.items {
+		if conv == s.Current {
+			s.browser.cursor = i
+		}
+	}
+}
+
+// updateBrowser handles input while the browser is open, other messages are handled as usual
+func (s *State) updateBrowser(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		s.browser.open = false
+		model, cmd := s.Update(msg)
+		s.browser.open = true
+		return model, cmd
+	}
+
+	b := &s.browser
+	if b.renaming {
+		return s, s.updateRename(keyMsg)
+	}
+	if b.deleting {
+		b.deleting = false
+		if keyMsg.String() == "y" && len(b.items) > 0 {
+			s.deleteConversation(b.items[b.cursor])
+		}
+		return s, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return s, tea.Quit
+	case "esc", "q", "ctrl+l":
+		b.open = false
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "down", "j":
+		if b.cursor < len(b.items)-1 {
+			b.cursor++
+		}
+	case "enter":
+		if len(b.items) > 0 {
+			b.open = false
+			s.open(b.items[b.cursor])
+		}
+	case "n":
+		b.open = false
+		s.open(s.Conversations.Add())
+		s.save()
+	case "r":
+		if len(b.items) > 0 {
+			b.renaming = true
+			b.input = textinput.New()
+			b.input.Prompt = "Name: "
+			b.input.SetValue(b.items[b.cursor].Name)
+			b.input.Focus()
+			return s, textinput.Blink
+		}
+	case "d":
+		if len(b.items) > 0 {
+			b.deleting = true
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if len(b.items) > 0 {
+			slot, _ := strconv.Atoi(keyMsg.String())
+			s.Conversations.Slots[slot-1] = b.items[b.cursor].ID
+			s.save()
+		}
+	}
+	return s, nil
+}
+
+func (s *State) updateRename(msg tea.KeyMsg) tea.Cmd {
+	b := &s.browser
+	switch msg.Type {
+	case tea.KeyEnter:
+		b.items[b.cursor].Name = strings.TrimSpace(b.input.Value())
+		b.items[b.cursor].Updated = time.Now()
+		b.renaming = false
+		s.save()
+		return nil
+	case tea.KeyEsc:
+		b.renaming = false
+		return nil
+	}
+	var cmd tea.Cmd
+	b.input, cmd = b.input.Update(msg)
+	return cmd
+}
+
+// deleteConversation removes conv, moving to another conversation if it was shown
+func (s *State) deleteConversation(conv *Conversation) {
+	s.Conversations.Delete(conv)
+	s.browser.items = s.Conversations.ByActivity()
+	if s.browser.cursor >= len(s.browser.items) && s.browser.cursor > 0 {
+		s.browser.cursor--
+	}
+	if conv == s.Current {
+		if len(s.browser.items) > 0 {
+			s.Current = s.browser.items[0]
+		} else {
+			s.Current = s.Conversations.Slot(0)
+			s.browser.items = s.Conversations.ByActivity()
+		}
+		s.refreshViewport()
+	}
+	s.save()
+}
+
+var browserCursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
+
+// browserView replaces the viewport while the browser is open
+func (s State) browserView() string {
+	b := s.browser
+	width := s.Viewport.Width - s.Viewport.Style.GetHorizontalFrameSize()
+	height := s.Viewport.Height
+	if height < 1 {
+		height = 1
+	}
+
+	// Keep the cursor in view
+	start := 0
+	if b.cursor >= height {
+		start = b.cursor - height + 1
+	}
+
+	lines := make([]string, 0, height)
+	for i := start; i < len(b.items) && len(lines) < height; i++ {
+		conv := b.items[i]
+		slot := "  "
+		if n := s.Conversations.SlotOf(conv); n != -1 {
+			slot = "F" + strconv.Itoa(n+1)
+		}
+		line := slot + "  " + conv.Title() +
+			"  (" + strconv.Itoa(len(conv.Messages)) + " messages, " + ago(conv.Updated) + ")"
+		line = lipgloss.NewStyle().MaxWidth(width - 2).Render(line)
+		if i == b.cursor {
+			line = browserCursorStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if len(b.items) == 0 {
+		lines = append(lines, "No saved conversations, press n to start one")
+	}
+	return s.Viewport.Style.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// browserStatus replaces the textarea while the browser is open
+func (s State) browserStatus() string {
+	b := s.browser
+	status := s.Textarea.Prompt + "Conversations: enter open, n new, r rename, d delete, 1-9 pin to F1-F9, esc close"
+	switch {
+	case b.renaming:
+		status = s.Textarea.Prompt + b.input.View() + "  (enter save, esc cancel)"
+	case b.deleting && len(b.items) > 0:
+		status = s.Textarea.Prompt + "Delete \"" + b.items[b.cursor].Title() + "\"? y to confirm, any other key cancels"
+	}
+	return lipgloss.NewStyle().Height(s.Textarea.Height()).Render(status)
+}
+
+// ago is a short, human readable age
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case undated(t):
+		return "undated"
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return strconv.Itoa(int(d.Minutes())) + "m ago"
+	case d < 24*time.Hour:
+		return strconv.Itoa(int(d.Hours())) + "h ago"
+	}
+	return strconv.Itoa(int(d.Hours()/24)) + "d ago"
+}
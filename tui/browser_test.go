@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUpdateBrowser(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s := &State{Conversations: &Conversations{}}
+	older := s.Conversations.Slot(0)
+	older.Updated = time.Now().Add(-time.Hour)
+	newer := s.Conversations.Add()
+	s.Current = older
+
+	s.openBrowser()
+	if !s.browser.open || s.browser.items[s.browser.cursor] != older {
+		t.Fatalf("Expected cursor on the current conversation")
+	}
+
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyUp})
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	if s.Conversations.SlotOf(newer) != 2 {
+		t.Errorf("Expected conversation pinned to slot 3, got %d", s.Conversations.SlotOf(newer))
+	}
+
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("notes")})
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyEnter})
+	if newer.Name != "notes" {
+		t.Errorf("Expected rename to notes, got %q", newer.Name)
+	}
+
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyEnter})
+	if s.browser.open || s.Current != newer {
+		t.Errorf("Expected enter to open the highlighted conversation")
+	}
+
+	s.openBrowser()
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	s.updateBrowser(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if s.Conversations.Find("notes") != nil {
+		t.Error("Expected conversation to be deleted")
+	}
+	if s.Current != older {
+		t.Error("Expected deleting the open conversation to switch to another")
+	}
+}
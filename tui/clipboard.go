@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Output is the program's terminal, pass it to tea.WithOutput. Writes are serialized so an
+// OSC 52 sequence sent from a command can't land in the middle of a frame.
+var Output = &terminal{file: os.Stdout}
+
+// terminal is a term.File whose writes don't interleave
+type terminal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Write(p)
+}
+
+func (t *terminal) Read(p []byte) (int, error) { return t.file.Read(p) }
+func (t *terminal) Close() error               { return t.file.Close() }
+func (t *terminal) Fd() uintptr                { return t.file.Fd() }
+
+// clipboardMsg reports the result of a copy
+type clipboardMsg struct {
+	what string
+	err  error
+}
+
+func copyCmd(text string, what string) tea.Cmd {
+	return func() tea.Msg {
+		return clipboardMsg{what: what, err: copyToClipboard(text)}
+	}
+}
+
+// copyToClipboard uses the system clipboard, falling back to an OSC 52 escape sequence.
+// Over SSH the system clipboard belongs to the remote machine, so OSC 52 is used directly.
+func copyToClipboard(text string) error {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(Output)
+	return err
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Columns narrower than this are stacked instead of placed side by side
+const minColumnWidth = 24
+
+var columnStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), false, true, false, false).
+	BorderForeground(lipgloss.Color("240")).
+	PaddingRight(1).
+	MarginRight(1)
+
+// renderCompletions lays out each model's answer in its own column, headed by the model's cost and word count.
+func renderCompletions(models Messages, width int, headerStyle lipgloss.Style, raw bool) string {
+	columns := len(models)
+	colWidth := width/columns - columnStyle.GetHorizontalFrameSize()
+	stacked := colWidth < minColumnWidth
+	if stacked {
+		colWidth = width
+	}
+
+	panes := make([]string, len(models))
+	for i, m := range models {
+		header := headerStyle.Render(m.Model) + "\n" +
+			"(" + strconv.FormatFloat(m.Coins, 'f', 2, 64) + " coins, " +
+			strconv.FormatInt(m.Words, 10) + " words)"
+		content := m.Content
+		if !raw {
+			content = markdownRenderer.Render(content, colWidth)
+		}
+		pane := lipgloss.NewStyle().Width(colWidth).Render(header + "\n" + content)
+		if !stacked && i < len(models)-1 {
+			pane = columnStyle.Render(pane)
+		}
+		panes[i] = pane
+	}
+
+	if stacked {
+		return strings.Join(panes, "\n\n")
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, panes...)
+}
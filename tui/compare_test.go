@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRenderCompletions(t *testing.T) {
+	models := Messages{
+		{Role: RoleAssistant, Model: "model-a", Content: "Answer A", Coins: 1.5, Words: 12},
+		{Role: RoleAssistant, Model: "model-b", Content: "Answer B", Coins: 2, Words: 20},
+	}
+
+	rendered := renderCompletions(models, 80, lipgloss.NewStyle(), true)
+
+	for _, want := range []string{"model-a", "model-b", "Answer A", "Answer B", "1.50 coins, 12 words", "2.00 coins, 20 words"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected rendered output to contain %q", want)
+		}
+	}
+
+	// Side by side, both model names share the first line
+	firstLine := strings.Split(rendered, "\n")[0]
+	if !strings.Contains(firstLine, "model-a") || !strings.Contains(firstLine, "model-b") {
+		t.Errorf("Expected models side by side, got first line %q", firstLine)
+	}
+
+	// Too narrow for columns, answers are stacked
+	stacked := renderCompletions(models, 30, lipgloss.NewStyle(), true)
+	firstLine = strings.Split(stacked, "\n")[0]
+	if strings.Contains(firstLine, "model-b") {
+		t.Errorf("Expected stacked models, got first line %q", firstLine)
+	}
+}
@@ -1,31 +1,241 @@
 package tui
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
 	"github.com/tyler71/straico-cli/m/v0/prompt"
-	"os"
-	"path/filepath"
-	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 const saveFile = "conversations.json"
 
+// SlotCount is the number of quick slots, bound to F1-F9
+const SlotCount = 9
+
 type Conversation struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name,omitempty"`
+	Updated       time.Time `json:"updated"`
 	pSelection    int
 	PromptHistory []string `json:"prompt_history"`
 	Messages      Messages `json:"messages"`
+	Persona       string   `json:"persona,omitempty"`
+	// Model replaces the configured models for this conversation, chosen with the model picker
+	Model string `json:"model,omitempty"`
+}
+
+// Conversations is every saved conversation, and the ones bound to the quick slots
+type Conversations struct {
+	List  []*Conversation   `json:"conversations"`
+	Slots [SlotCount]string `json:"slots"`
+	// store saves the conversations, the json file when not set
+	store Store
+}
+
+// NewConversations returns the conversations saved in backend, or none when nothing was saved yet
+func NewConversations(backend string) (*Conversations, error) {
+	store, err := OpenStore(backend)
+	if err != nil {
+		return &Conversations{store: &jsonStore{}}, err
+	}
+	conversations := &Conversations{store: store}
+	err = conversations.LoadConversations()
+	return conversations, err
+}
+
+// LoadConversations replaces the conversations with the saved ones
+func (c *Conversations) LoadConversations() error {
+	return c.storage().Load(c)
+}
+
+// SaveConversations writes the conversations to their store
+func (c *Conversations) SaveConversations() error {
+	return c.storage().Save(c)
+}
+
+// RecordUsage copies answers written to the usage ledger into the store
+func (c *Conversations) RecordUsage(entries []cmd.UsageEntry) error {
+	return c.storage().RecordUsage(entries)
+}
+
+// Close releases the store
+func (c *Conversations) Close() error {
+	return c.storage().Close()
 }
-type Conversations []Conversation
 
-func (c Conversations) InitConversation(channel int) {
-	c[channel] = Conversation{
+func (c *Conversations) storage() Store {
+	if c.store == nil {
+		c.store = &jsonStore{}
+	}
+	return c.store
+}
+
+// NewConversation returns an empty, unnamed conversation
+func NewConversation() *Conversation {
+	return &Conversation{
+		ID:            newID(),
+		Updated:       time.Now(),
 		PromptHistory: make([]string, 0, prompt.MaxContextLength),
 		Messages:      make(Messages, 0, prompt.MaxContextLength*2),
 		pSelection:    -1,
 	}
 }
 
+var lastID int64
+
+// newID is based on the current time, and unique within this process
+func newID() string {
+	id := time.Now().UnixNano()
+	if id <= lastID {
+		id = lastID + 1
+	}
+	lastID = id
+	return strconv.FormatInt(id, 36)
+}
+
+// Clear erases the history but keeps the name and persona
+func (c *Conversation) Clear() {
+	c.PromptHistory = make([]string, 0, prompt.MaxContextLength)
+	c.Messages = make(Messages, 0, prompt.MaxContextLength*2)
+	c.pSelection = -1
+	c.Updated = time.Now()
+}
+
+// Title is the conversation's name, or its first prompt when it has not been named
+func (c *Conversation) Title() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	for _, m := range c.Messages {
+		if m.Role == RoleUser {
+			title := strings.Join(strings.Fields(m.Content), " ")
+			if utf8.RuneCountInString(title) > 40 {
+				title = string([]rune(title)[:39]) + "…"
+			}
+			return title
+		}
+	}
+	return "New conversation"
+}
+
+// Find returns the conversation with the given id or name
+func (c *Conversations) Find(idOrName string) *Conversation {
+	if idOrName == "" {
+		return nil
+	}
+	for _, conv := range c.List {
+		if conv.ID == idOrName || conv.Name == idOrName {
+			return conv
+		}
+	}
+	return nil
+}
+
+// Slot returns the conversation in quick slot i, creating one if the slot is empty
+func (c *Conversations) Slot(i int) *Conversation {
+	if conv := c.Find(c.Slots[i]); conv != nil {
+		return conv
+	}
+	conv := c.Add()
+	c.Slots[i] = conv.ID
+	return conv
+}
+
+// SlotOf returns the quick slot conv is bound to, or -1
+func (c *Conversations) SlotOf(conv *Conversation) int {
+	for i, id := range c.Slots {
+		if id != "" && id == conv.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add creates a new conversation
+func (c *Conversations) Add() *Conversation {
+	conv := NewConversation()
+	c.List = append(c.List, conv)
+	return conv
+}
+
+// Delete removes conv and unbinds it from its quick slot
+func (c *Conversations) Delete(conv *Conversation) {
+	for i, existing := range c.List {
+		if existing == conv {
+			c.List = append(c.List[:i], c.List[i+1:]...)
+			break
+		}
+	}
+	for i, id := range c.Slots {
+		if id == conv.ID {
+			c.Slots[i] = ""
+		}
+	}
+}
+
+// ByActivity returns the conversations, most recently active first
+func (c *Conversations) ByActivity() []*Conversation {
+	sorted := make([]*Conversation, len(c.List))
+	copy(sorted, c.List)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Updated.After(sorted[j].Updated)
+	})
+	return sorted
+}
+
+// UnmarshalJSON reads the conversation list, or the nine buffers older versions saved
+func (c *Conversations) UnmarshalJSON(data []byte) error {
+	type conversations Conversations
+	var loaded conversations
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var buffers []*Conversation
+		if err := json.Unmarshal(data, &buffers); err != nil {
+			return err
+		}
+		for i, conv := range buffers {
+			if len(conv.Messages) == 0 || i >= SlotCount {
+				continue
+			}
+			// The same in every window that migrates the file
+			conv.ID = "buffer" + strconv.Itoa(i+1)
+			if conv.Updated.IsZero() {
+				conv.Updated = conv.Messages[len(conv.Messages)-1].Timestamp
+			}
+			// Messages saved as styled strings have no time, keep the buffer order.
+			// The time must not change between reads, or saving would take the file for another window's.
+			if conv.Updated.IsZero() {
+				conv.Updated = undatedTime(i)
+			}
+			loaded.List = append(loaded.List, conv)
+			loaded.Slots[i] = conv.ID
+		}
+	} else if err := json.Unmarshal(data, &loaded); err != nil {
+		return err
+	}
+
+	for _, conv := range loaded.List {
+		conv.pSelection = -1
+	}
+	loaded.store = c.store
+	*c = Conversations(loaded)
+	return nil
+}
+
+// undatedTime orders the conversations of buffers saved without a time, buffer 1 being the most recent
+func undatedTime(buffer int) time.Time {
+	return time.Unix(int64(SlotCount-buffer), 0).UTC()
+}
+
+// undated reports whether t was given by undatedTime
+func undated(t time.Time) bool {
+	return t.Before(time.Unix(SlotCount+1, 0))
+}
+
 // RecentPrompt 1 to get the prompt to the right, -1 to get the prompt to the left
 // 0 to reset to the end
 func (c *Conversation) RecentPrompt(direction int) string {
@@ -56,67 +266,3 @@ func (c *Conversation) RecentPrompt(direction int) string {
 	}
 	return ""
 }
-
-func (c Conversations) getConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("error getting home directory: %w", err)
-	}
-
-	switch runtime.GOOS {
-	case "windows":
-		return filepath.Join(home, "AppData", "Roaming", "straico-cli"), nil
-	case "darwin":
-		return filepath.Join(home, "Library", "Application Support", "straico-cli"), nil
-	default: // linux and others
-		return filepath.Join(home, ".config", "straico-cli"), nil
-	}
-}
-
-func (c Conversations) LoadConversations() error {
-	configDir, err := c.getConfigDir()
-	if err != nil {
-		return err
-	}
-
-	configPath := filepath.Join(configDir, saveFile)
-
-	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("error creating config directory: %w", err)
-	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // Return nil if file doesn't exist
-		}
-		return fmt.Errorf("error reading config file: %w", err)
-	}
-
-	if err := json.Unmarshal(data, &c); err != nil {
-		return fmt.Errorf("error parsing config file: %w", err)
-	}
-
-	return nil
-}
-
-func (c *Conversations) SaveConversations() error {
-	configDir, err := c.getConfigDir()
-	if err != nil {
-		return err
-	}
-
-	configPath := filepath.Join(configDir, saveFile)
-
-	encodedConfig, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error serializing config file: %w", err)
-	}
-
-	err = os.WriteFile(configPath, encodedConfig, 0644)
-	if err != nil {
-		return fmt.Errorf("unable to write to config file %w", err)
-	}
-	return err
-}
@@ -2,21 +2,18 @@ package tui
 
 import (
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"strings"
 	"testing"
-)
+	"time"
 
-func TestConversationInitConversation(t *testing.T) {
-	conversations := make(Conversations, 3)
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+)
 
-	// Initialize all conversations
-	for i := range conversations {
-		conversations.InitConversation(i)
-	}
+func TestNewConversation(t *testing.T) {
+	a, b := NewConversation(), NewConversation()
 
-	// Check that each conversation was initialized properly
-	for i, conv := range conversations {
+	for i, conv := range []*Conversation{a, b} {
 		if conv.PromptHistory == nil {
 			t.Errorf("Conversation %d: Expected non-nil PromptHistory", i)
 		}
@@ -25,116 +22,172 @@ func TestConversationInitConversation(t *testing.T) {
 			t.Errorf("Conversation %d: Expected non-nil Messages", i)
 		}
 
-		if len(conv.PromptHistory) != 0 {
-			t.Errorf("Conversation %d: Expected empty PromptHistory, got %d items", i, len(conv.PromptHistory))
-		}
-
 		if len(conv.Messages) != 0 {
 			t.Errorf("Conversation %d: Expected empty Messages, got %d items", i, len(conv.Messages))
 		}
 	}
+	if a.ID == "" || a.ID == b.ID {
+		t.Errorf("Expected unique ids, got %q and %q", a.ID, b.ID)
+	}
 }
 
 func TestConversationSaveAndLoad(t *testing.T) {
-	// Create a temporary directory for testing
-	tempDir, err := os.MkdirTemp("", "straico-cli-test-conversations")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
+	t.Setenv("HOME", t.TempDir())
+
+	original := &Conversations{}
+	first := original.Slot(0)
+	first.Name = "project"
+	first.PromptHistory = append(first.PromptHistory, "Test prompt 1")
+	first.Messages = append(first.Messages, Message{Role: RoleUser, Content: "Test message 1"})
+	first.Messages = append(first.Messages, Message{Role: RoleAssistant, Content: "Test response 1", Model: "test-model", Coins: 0.3})
+
+	// More conversations than there are quick slots
+	for i := 0; i < SlotCount+3; i++ {
+		conv := original.Add()
+		conv.Messages = append(conv.Messages, Message{Role: RoleAssistant, Content: "Test response 2", Error: true})
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Create test conversations
-	originalConversations := make(Conversations, 2)
-	for i := range originalConversations {
-		originalConversations.InitConversation(i)
+	if err := original.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save conversations: %v", err)
 	}
-
-	// Add some data to the conversations
-	originalConversations[0].PromptHistory = append(originalConversations[0].PromptHistory, "Test prompt 1")
-	originalConversations[0].Messages = append(originalConversations[0].Messages, "User: Test message 1")
-	originalConversations[0].Messages = append(originalConversations[0].Messages, "LLM: Test response 1")
-
-	originalConversations[1].PromptHistory = append(originalConversations[1].PromptHistory, "Test prompt 2")
-	originalConversations[1].Messages = append(originalConversations[1].Messages, "User: Test message 2")
-	originalConversations[1].Messages = append(originalConversations[1].Messages, "LLM: Test response 2")
-
-	// Save the conversations directly to a file in the temp directory
-	configPath := filepath.Join(tempDir, "conversations.json")
-	encodedConversations, err := json.MarshalIndent(originalConversations, "", "  ")
+	loaded, err := NewConversations(StoreJSON)
 	if err != nil {
-		t.Fatalf("Failed to marshal conversations: %v", err)
+		t.Fatalf("Failed to load conversations: %v", err)
 	}
 
-	if err := os.WriteFile(configPath, encodedConversations, 0644); err != nil {
-		t.Fatalf("Failed to write conversations file: %v", err)
+	if len(loaded.List) != len(original.List) {
+		t.Fatalf("Expected %d conversations, got %d", len(original.List), len(loaded.List))
+	}
+	if loaded.Slots != original.Slots {
+		t.Errorf("Expected slots %v, got %v", original.Slots, loaded.Slots)
 	}
 
-	// Load the conversations from the file
-	var loadedConversations Conversations
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		t.Fatalf("Failed to read conversations file: %v", err)
+	conv := loaded.Find("project")
+	if conv == nil || conv != loaded.Slot(0) {
+		t.Fatalf("Expected the named conversation in slot 1")
+	}
+	if len(conv.PromptHistory) != 1 || conv.PromptHistory[0] != "Test prompt 1" {
+		t.Errorf("Expected prompt history to be kept, got %v", conv.PromptHistory)
+	}
+	for j, message := range first.Messages {
+		if conv.Messages[j] != message {
+			t.Errorf("Message %d: Expected %+v, got %+v", j, message, conv.Messages[j])
+		}
 	}
+}
+
+func TestConversationsUnmarshalLegacy(t *testing.T) {
+	data := []byte(`[
+		{"prompt_history": ["Hello"], "messages": [{"role": "user", "content": "Hello", "timestamp": "2025-01-02T03:04:05Z"}]},
+		{"prompt_history": [], "messages": []},
+		{"prompt_history": ["Hi"], "messages": ["\u001b[35mYou: \u001b[0mHi"]}
+	]`)
 
-	if err := json.Unmarshal(data, &loadedConversations); err != nil {
+	var conversations Conversations
+	if err := json.Unmarshal(data, &conversations); err != nil {
 		t.Fatalf("Failed to unmarshal conversations: %v", err)
 	}
 
-	// Verify the loaded conversations match the original
-	if len(loadedConversations) != len(originalConversations) {
-		t.Fatalf("Expected %d conversations, got %d",
-			len(originalConversations), len(loadedConversations))
+	if len(conversations.List) != 2 {
+		t.Fatalf("Expected empty buffers to be dropped, got %d conversations", len(conversations.List))
 	}
+	if conversations.Slots[1] != "" {
+		t.Errorf("Expected slot 2 to be empty, got %q", conversations.Slots[1])
+	}
+	if got := conversations.Slot(0).Title(); got != "Hello" {
+		t.Errorf("Expected buffer 1 in slot 1, got %q", got)
+	}
+	if got := conversations.Slot(2).Title(); got != "Hi" {
+		t.Errorf("Expected buffer 3 in slot 3, got %q", got)
+	}
+}
 
-	for i := range originalConversations {
-		if len(loadedConversations[i].PromptHistory) != len(originalConversations[i].PromptHistory) {
-			t.Errorf("Conversation %d: Expected %d prompts, got %d",
-				i, len(originalConversations[i].PromptHistory), len(loadedConversations[i].PromptHistory))
-			continue
-		}
+func TestConversationTitle(t *testing.T) {
+	c := NewConversation()
+	if got := c.Title(); got != "New conversation" {
+		t.Errorf("Expected placeholder title, got %q", got)
+	}
 
-		if len(loadedConversations[i].Messages) != len(originalConversations[i].Messages) {
-			t.Errorf("Conversation %d: Expected %d messages, got %d",
-				i, len(originalConversations[i].Messages), len(loadedConversations[i].Messages))
-			continue
-		}
+	c.Messages = Messages{{Role: RoleUser, Content: "Explain  the\nbuild " + strings.Repeat("x", 50)}}
+	if got := c.Title(); !strings.HasPrefix(got, "Explain the build x") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Expected truncated first prompt, got %q", got)
+	}
 
-		// Check prompt history
-		for j, prompt := range originalConversations[i].PromptHistory {
-			if loadedConversations[i].PromptHistory[j] != prompt {
-				t.Errorf("Conversation %d, Prompt %d: Expected %q, got %q",
-					i, j, prompt, loadedConversations[i].PromptHistory[j])
-			}
-		}
+	c.Name = "build notes"
+	if got := c.Title(); got != "build notes" {
+		t.Errorf("Expected name as title, got %q", got)
+	}
+}
 
-		// Check messages
-		for j, message := range originalConversations[i].Messages {
-			if loadedConversations[i].Messages[j] != message {
-				t.Errorf("Conversation %d, Message %d: Expected %q, got %q",
-					i, j, message, loadedConversations[i].Messages[j])
-			}
-		}
+func TestConversationsDelete(t *testing.T) {
+	conversations := &Conversations{}
+	conv := conversations.Slot(4)
+	if got := conversations.SlotOf(conv); got != 4 {
+		t.Errorf("Expected slot 4, got %d", got)
+	}
+
+	conversations.Delete(conv)
+	if len(conversations.List) != 0 {
+		t.Errorf("Expected no conversations, got %d", len(conversations.List))
+	}
+	if conversations.Slots[4] != "" {
+		t.Errorf("Expected slot to be unbound, got %q", conversations.Slots[4])
 	}
 }
 
 func TestMessagesRender(t *testing.T) {
 	messages := Messages{
-		"Message 1",
-		"Message 2",
-		"Message 3",
+		{Role: RoleUser, Content: "Message 1"},
+		{Role: RoleAssistant, Content: "Message 2"},
+		{Role: RoleAssistant, Content: "Message 3", Error: true},
 	}
 
-	rendered := messages.Render(20)
+	rendered := messages.Render(20, lipgloss.NewStyle(), true)
 
-	// Check that the rendered output contains all messages
-	for _, msg := range messages {
-		if !contains(rendered, msg) {
-			t.Errorf("Expected rendered output to contain %q", msg)
+	for _, want := range []string{"You: Message 1", "LLM: Message 2", "Error: Message 3"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected rendered output to contain %q", want)
 		}
 	}
 }
 
-// Helper function to check if a string contains another string
-func contains(s, substr string) bool {
-	return s != "" && substr != "" && s != substr && len(s) > len(substr) && s[len(s)-1] != substr[len(substr)-1]
+func TestMessagesUnmarshalLegacy(t *testing.T) {
+	data := []byte(`[
+		"\u001b[35mYou: \u001b[0mHello",
+		"\u001b[35mLLM: \u001b[0mHi there",
+		"\u001b[35mError: \u001b[0mrequest failed. Error: 401 Unauthorized",
+		{"role": "user", "content": "Typed", "timestamp": "2025-01-02T03:04:05Z"}
+	]`)
+
+	var messages Messages
+	if err := json.Unmarshal(data, &messages); err != nil {
+		t.Fatalf("Failed to unmarshal messages: %v", err)
+	}
+
+	want := Messages{
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi there"},
+		{Role: RoleAssistant, Content: "request failed. Error: 401 Unauthorized", Error: true},
+		{Role: RoleUser, Content: "Typed", Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %d", len(want), len(messages))
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Message %d: Expected %+v, got %+v", i, want[i], messages[i])
+		}
+	}
+}
+
+func TestCyclePersona(t *testing.T) {
+	s := State{Config: cmd.ConfigFile{Personas: []cmd.Persona{{Name: "reviewer"}, {Name: "translator"}}}}
+	c := &Conversation{}
+
+	for _, want := range []string{"reviewer", "translator", "", "reviewer"} {
+		s.cyclePersona(c)
+		if c.Persona != want {
+			t.Errorf("Expected persona %q, got %q", want, c.Persona)
+		}
+	}
 }
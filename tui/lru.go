@@ -0,0 +1,47 @@
+package tui
+
+import "container/list"
+
+// lru keeps at most max values, dropping the least recently used
+type lru[K comparable, V any] struct {
+	max   int
+	order *list.List
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](max int) *lru[K, V] {
+	return &lru[K, V]{max: max, order: list.New(), items: make(map[K]*list.Element)}
+}
+
+func (l *lru[K, V]) Get(key K) (V, bool) {
+	element, ok := l.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+func (l *lru[K, V]) Put(key K, value V) {
+	if element, ok := l.items[key]; ok {
+		element.Value.(*lruEntry[K, V]).value = value
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry[K, V]{key, value})
+	if l.order.Len() > l.max {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (l *lru[K, V]) Len() int {
+	return l.order.Len()
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// markdown renders LLM answers with syntax highlighted code blocks.
+// Renderers and output are cached, since glamour is slow to set up. Both caches are bounded:
+// a resize or a streamed answer would otherwise keep every width and version seen.
+type markdown struct {
+	renderers *lru[int, *glamour.TermRenderer]
+	cache     *lru[renderedKey, string]
+}
+
+type renderedKey struct {
+	width   int
+	content string
+}
+
+// The viewport and the columns of compared answers each render at their own width
+var markdownRenderer = &markdown{
+	renderers: newLRU[int, *glamour.TermRenderer](4),
+	cache:     newLRU[renderedKey, string](256),
+}
+
+// Render returns content as styled markdown wrapped to width,
+// falling back to the raw text if it can't be rendered.
+func (md *markdown) Render(content string, width int) string {
+	if width < 1 {
+		return content
+	}
+	key := renderedKey{width, content}
+	if rendered, ok := md.cache.Get(key); ok {
+		return rendered
+	}
+
+	renderer, ok := md.renderers.Get(width)
+	if !ok {
+		var err error
+		renderer, err = glamour.NewTermRenderer(markdownStyle(), glamour.WithWordWrap(width))
+		if err != nil {
+			return content
+		}
+		md.renderers.Put(width, renderer)
+	}
+
+	rendered, err := renderer.Render(content)
+	if err != nil {
+		return content
+	}
+	rendered = strings.Trim(rendered, "\n")
+	md.cache.Put(key, rendered)
+	return rendered
+}
+
+// markdownStyle honours GLAMOUR_STYLE, otherwise uses the dark style.
+// Auto detection queries the terminal, which conflicts with bubbletea reading input.
+func markdownStyle() glamour.TermRendererOption {
+	if os.Getenv("GLAMOUR_STYLE") != "" {
+		return glamour.WithEnvironmentConfig()
+	}
+	return glamour.WithStandardStyle("dark")
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMarkdownRender(t *testing.T) {
+	content := "# Title\n\n- item one\n\n```go\nfmt.Println(\"hi\")\n```"
+
+	rendered := ansi.Strip(markdownRenderer.Render(content, 40))
+
+	if strings.Contains(rendered, "```") || strings.Contains(rendered, "- item") {
+		t.Errorf("Expected markdown syntax to be rendered, got %q", rendered)
+	}
+	for _, want := range []string{"Title", "item one", `fmt.Println("hi")`} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected rendered output to contain %q, got %q", want, rendered)
+		}
+	}
+	for _, line := range strings.Split(rendered, "\n") {
+		if lipgloss.Width(line) > 40 {
+			t.Errorf("Expected lines to fit width 40, got %d: %q", lipgloss.Width(line), line)
+		}
+	}
+}
+
+func TestMessagesRenderRawView(t *testing.T) {
+	messages := Messages{{Role: RoleAssistant, Content: "**bold**"}}
+
+	if raw := messages.Render(40, lipgloss.NewStyle(), true); !strings.Contains(raw, "**bold**") {
+		t.Errorf("Expected raw view to keep markdown source, got %q", raw)
+	}
+	if rendered := ansi.Strip(messages.Render(40, lipgloss.NewStyle(), false)); strings.Contains(rendered, "**") {
+		t.Errorf("Expected markdown view to render emphasis, got %q", rendered)
+	}
+}
+
+func TestMarkdownRenderBoundsCache(t *testing.T) {
+	md := &markdown{renderers: newLRU[int, *glamour.TermRenderer](2), cache: newLRU[renderedKey, string](3)}
+
+	for width := 20; width < 30; width++ {
+		md.Render("answer", width)
+	}
+	for i := range 10 {
+		md.Render(strings.Repeat("streamed ", i+1), 20)
+	}
+
+	if md.renderers.Len() != 2 || md.cache.Len() != 3 {
+		t.Errorf("Expected 2 renderers and 3 rendered answers kept, got %d and %d", md.renderers.Len(), md.cache.Len())
+	}
+	if _, ok := md.cache.Get(renderedKey{20, strings.Repeat("streamed ", 10)}); !ok {
+		t.Error("Expected the latest answer to stay cached")
+	}
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+const (
+	RoleUser      = prompt.RoleUser
+	RoleAssistant = prompt.RoleAssistant
+)
+
+// Message is a single entry of a conversation. Styling is applied when rendering, never stored.
+type Message struct {
+	Role         string    `json:"role"`
+	Content      string    `json:"content"`
+	Model        string    `json:"model,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	Coins        float64   `json:"coins,omitempty"`
+	Tokens       int64     `json:"tokens,omitempty"`
+	Words        int64     `json:"words,omitempty"`
+	FinishReason string    `json:"finish_reason,omitempty"`
+	Error        bool      `json:"error,omitempty"`
+}
+
+type Messages []Message
+
+// renderedBlock is the display of one message, or of a group of answers shown side by side
+type renderedBlock struct {
+	first, last int
+	text        string
+}
+
+// Render styles the conversation for display, answers are shown as markdown unless raw is set.
+// Consecutive answers from several models to the same prompt are shown side by side.
+func (m Messages) Render(width int, senderStyle lipgloss.Style, raw bool) string {
+	blocks := m.renderBlocks(width, senderStyle, raw)
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.text
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (m Messages) renderBlocks(width int, senderStyle lipgloss.Style, raw bool) []renderedBlock {
+	wrap := lipgloss.NewStyle().Width(width)
+	blocks := make([]renderedBlock, 0, len(m))
+	for i := 0; i < len(m); i++ {
+		msg := m[i]
+		block := renderedBlock{first: i, last: i}
+		switch {
+		case msg.Role == RoleUser:
+			block.text = wrap.Render(senderStyle.Render("You: ") + msg.Content)
+		case msg.Error:
+			block.text = wrap.Render(senderStyle.Render("Error: ") + msg.Content)
+		default:
+			group := m.answerGroup(i)
+			if len(group) > 1 {
+				block.text = senderStyle.Render("LLM:") + "\n" + renderCompletions(group, width, senderStyle, raw)
+				block.last = i + len(group) - 1
+				i = block.last
+			} else if raw {
+				block.text = wrap.Render(senderStyle.Render("LLM: ") + msg.Content)
+			} else {
+				block.text = senderStyle.Render("LLM:") + "\n" + markdownRenderer.Render(msg.Content, width)
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// answerGroup returns the run of successful model answers starting at i
+func (m Messages) answerGroup(i int) Messages {
+	end := i
+	for end < len(m) && m[end].Role == RoleAssistant && !m[end].Error {
+		end++
+	}
+	return m[i:end]
+}
+
+// Context returns the successful messages as turns for the next request
+func (m Messages) Context() []prompt.Turn {
+	turns := make([]prompt.Turn, 0, len(m))
+	for _, msg := range m {
+		if msg.Error {
+			continue
+		}
+		turns = append(turns, prompt.Turn{Role: msg.Role, Content: msg.Content})
+	}
+	return turns
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// UnmarshalJSON reads both message records and the pre-styled strings older versions saved.
+func (m *Messages) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	messages := make(Messages, 0, len(raw))
+	for _, r := range raw {
+		var legacy string
+		if err := json.Unmarshal(r, &legacy); err == nil {
+			messages = append(messages, legacyMessage(legacy))
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(r, &msg); err != nil {
+			return err
+		}
+		messages = append(messages, msg)
+	}
+	*m = messages
+	return nil
+}
+
+// legacyMessage converts a saved "You: "/"LLM: "/"Error: " string into a Message
+func legacyMessage(s string) Message {
+	s = ansiEscape.ReplaceAllString(s, "")
+	switch {
+	case strings.HasPrefix(s, "You: "):
+		return Message{Role: RoleUser, Content: strings.TrimPrefix(s, "You: ")}
+	case strings.HasPrefix(s, "Error: "):
+		return Message{Role: RoleAssistant, Content: strings.TrimPrefix(s, "Error: "), Error: true}
+	case strings.HasPrefix(s, "LLM: "):
+		return Message{Role: RoleAssistant, Content: strings.TrimPrefix(s, "LLM: ")}
+	}
+	return Message{Role: RoleAssistant, Content: s}
+}
@@ -1,32 +1,35 @@
 package tui
 
 import (
+	"errors"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const gap = "\n\n"
 
+const welcome = `Welcome to Straico Cli!
+Type a message and press Enter to send, or /help for commands.
+
+
+Use ↑/↓ arrows to scroll through chat history.`
+
 // LLMResponseMsg represents a message containing the LLM response
 type LLMResponseMsg struct {
-	response  string
-	coinUsage float64
-	err       error
-}
-type Messages []string
-
-func (m Messages) Render(width int) string {
-	return lipgloss.NewStyle().Width(width).Render(strings.Join(m, "\n"))
+	conversation *Conversation
+	result       prompt.Result
+	err          error
 }
 
 func NewModel(config *cmd.ConfigFile, state *State) *State {
 	ta := textarea.New()
-	ta.Placeholder = "Ask the LLM... (" + config.Model + ")" + " "
 	ta.Focus()
 
 	ta.Prompt = "┃ "
@@ -50,30 +53,90 @@ func NewModel(config *cmd.ConfigFile, state *State) *State {
 
 	ta.KeyMap.InsertNewline.SetEnabled(false)
 
-	conversations := make(Conversations, 9)
-	for i := 0; i < 9; i++ {
-		conversations.InitConversation(i)
+	conversations, err := NewConversations(config.Store)
+	if err != nil {
+		state.Err = err
+	}
+	current := conversations.Slot(0)
+	if config.Persona != "" {
+		current.Persona = config.Persona
 	}
-	conversations.LoadConversations()
 
 	state.Textarea = ta
-	state.ConvSelection = 0
 	state.Conversations = conversations
+	state.Current = current
 	state.Viewport = vp
 	state.SenderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
 	state.Config = *config
+	state.spentToday, _ = cmd.SpentToday()
+	state.Textarea.Placeholder = "Ask the LLM... (" + state.modelLabel() + ")" + state.profileLabel() + " "
+	if warning := config.Warning(); warning != "" {
+		state.notice = warning
+	}
+	if state.Err != nil {
+		state.notice = state.Err.Error()
+	}
+	if state.notice != "" {
+		state.Textarea.Placeholder = state.notice
+	}
 	return state
 
 }
 
 func (s State) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, loadModels(s.Config.Key), loadBalance(s.Config.Key))
+}
+
+// modelsMsg carries the model catalog used to size the context window
+type modelsMsg struct {
+	models []cmd.Models
+	err    error
+}
+
+func loadModels(key string) tea.Cmd {
+	return func() tea.Msg {
+		models, err := cmd.GetModels(key)
+		return modelsMsg{models: models, err: err}
+	}
+}
+
+// balanceMsg carries the account, to show the coins left
+type balanceMsg struct {
+	user cmd.User
+	err  error
+}
+
+func loadBalance(key string) tea.Cmd {
+	return func() tea.Msg {
+		user, err := cmd.GetUser(key)
+		return balanceMsg{user: user, err: err}
+	}
 }
 
 func (s *State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	c := &s.Conversations[s.ConvSelection]
+	c := s.Current
+	var next tea.Cmd
 
-	s.Textarea, _ = s.Textarea.Update(msg)
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && s.Selecting {
+		return s.updateSelection(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && s.exporting {
+		return s.updateExport(keyMsg)
+	}
+	if s.browser.open {
+		return s.updateBrowser(msg)
+	}
+	if s.search.open {
+		return s.updateSearch(msg)
+	}
+	if s.picker.open {
+		return s.updatePicker(msg)
+	}
+
+	// The textarea reads ctrl+p as the previous line, cycling personas must not move the cursor
+	if keyMsg, ok := msg.(tea.KeyMsg); !ok || keyMsg.Type != tea.KeyCtrlP {
+		s.Textarea, _ = s.Textarea.Update(msg)
+	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -84,25 +147,80 @@ func (s *State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.Viewport.Height = msg.Height - s.Textarea.Height() - h - 1
 
 		if len(c.Messages) > -1 {
-			s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
+			s.refreshViewport()
+		}
+
+	case modelsMsg:
+		// Without the catalog the default context window is used
+		if msg.err == nil {
+			s.models = msg.models
+			s.Config.Prompt.WordLimit, s.Config.Prompt.MaxOutput = cmd.ContextLimits(msg.models, s.Config.Prompt.Model)
+		}
+		s.modelsErr = msg.err
+		if s.picker.open {
+			s.filterPicker()
+		}
+		return s, nil
+
+	case balanceMsg:
+		// A failed refresh keeps the last known balance
+		if msg.err == nil {
+			s.updateBalance(msg.user.Coins)
 		}
 
 	case LLMResponseMsg:
+		// The answer belongs to the conversation that asked, even if another is shown now
+		c := msg.conversation
+		c.Updated = time.Now()
 		if msg.err != nil {
-			c.Messages = append(c.Messages, s.SenderStyle.Render("Error: ")+msg.err.Error())
+			c.Messages = append(c.Messages, Message{
+				Role:      RoleAssistant,
+				Content:   msg.err.Error(),
+				Timestamp: time.Now(),
+				Error:     true,
+			})
 		} else {
-			c.Messages = append(c.Messages, s.SenderStyle.Render("LLM: ")+msg.response)
-			s.CoinUsage += msg.coinUsage
+			for _, m := range msg.result.Models {
+				c.Messages = append(c.Messages, Message{
+					Role:         RoleAssistant,
+					Content:      m.Content,
+					Model:        m.Model,
+					Timestamp:    time.Now(),
+					Coins:        m.Price.Total,
+					Tokens:       m.Usage.TotalTokens,
+					Words:        int64(m.Words.Total),
+					FinishReason: m.FinishReason,
+				})
+			}
+			s.CoinUsage += msg.result.Price.Total
+			s.spentToday += msg.result.Price.Total
+			entries := cmd.UsageEntries(msg.result, cmd.SourceChat, c.ID, s.Config.ActiveProfile)
+			if err := cmd.RecordUsage(entries); err != nil {
+				s.notice = "Unable to record usage: " + err.Error()
+			} else if err := s.Conversations.RecordUsage(entries); err != nil {
+				s.notice = "Unable to record usage: " + err.Error()
+			}
+		}
+		s.save()
+		// The answer was paid for, or failed perhaps for lack of coins
+		next = loadBalance(s.Config.Key)
+		if c != s.Current {
+			if s.notice == "" {
+				s.notice = "New answer in " + c.Title()
+			}
+			break
 		}
-		s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
+		s.refreshViewport()
 		if len(c.PromptHistory) > 1 {
 			s.Viewport.HalfViewDown()
 		}
 		//s.Viewport.GotoBottom()
-		err := s.Conversations.SaveConversations()
-		if err != nil {
-			s.Err = err
-			return s, nil
+
+	case clipboardMsg:
+		if msg.err != nil {
+			s.notice = "Unable to copy " + msg.what + ": " + msg.err.Error()
+		} else {
+			s.notice = "Copied " + msg.what
 		}
 
 	case tea.MouseMsg:
@@ -112,6 +230,7 @@ func (s *State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
+		s.notice = ""
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			//coinUsageMessage := strconv.FormatFloat(s.CoinUsage, 'f', 2, 64) + " coins used during session"
@@ -137,51 +256,66 @@ func (s *State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.Textarea.Reset()
 				return s, nil
 			}
+			if line, ok := slashLine(userMessage); ok {
+				s.Textarea.Reset()
+				s.updatePlaceholder()
+				return s, s.runSlash(line)
+			}
+			// A doubled slash sends the message with a single one
+			if strings.HasPrefix(userMessage, "//") {
+				userMessage = userMessage[1:]
+			}
+			// The prompt stays in the textarea, whose hint explains why
+			if s.checkBudget(c, userMessage) != nil {
+				return s, nil
+			}
 			c.PromptHistory = append(c.PromptHistory, userMessage)
 			c.RecentPrompt(0)
-			c.Messages = append(c.Messages, s.SenderStyle.Render("You: ")+userMessage)
-			s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
-			s.Textarea.Reset()
-			s.Viewport.GotoBottom()
-			s.Textarea.Placeholder = "Loading..."
-
-			return s, func() tea.Msg {
-				response, err := s.Config.Prompt.Request(s.Config.Key, userMessage, c.PromptHistory)
-				if err != nil {
-					return LLMResponseMsg{err: err}
-				}
-				llmResponse := response.Data.Completions[s.Config.Prompt.Model[0]].Completion.Choices[0].Message.Content
-				coins := response.Data.OverallPrice.Total
-				return LLMResponseMsg{response: llmResponse, coinUsage: coins}
-			}
+			return s, s.send(c, userMessage)
+		case tea.KeyTab:
+			s.completeSlash()
 		case tea.KeyF1, tea.KeyF2, tea.KeyF3, tea.KeyF4, tea.KeyF5, tea.KeyF6, tea.KeyF7, tea.KeyF8, tea.KeyF9:
-			s.ConvSelection = int(tea.KeyF1 - msg.Type)
-			c = &s.Conversations[s.ConvSelection]
-			s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
-		//	ShiftLeft and ShiftRight used to
+			c = s.Conversations.Slot(int(tea.KeyF1 - msg.Type))
+			s.open(c)
+		//	ShiftLeft and ShiftRight move the conversation to the neighbouring quick slot
 		case tea.KeyShiftLeft:
-			if s.ConvSelection-1 >= 0 {
-				t := s.Conversations[s.ConvSelection-1]
-				s.Conversations[s.ConvSelection-1] = s.Conversations[s.ConvSelection]
-				s.Conversations[s.ConvSelection] = t
-				s.ConvSelection--
-				c = &s.Conversations[s.ConvSelection]
-				s.Conversations.SaveConversations()
-				s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
+			if slot := s.Conversations.SlotOf(c); slot-1 >= 0 {
+				s.Conversations.Slots[slot-1], s.Conversations.Slots[slot] = s.Conversations.Slots[slot], s.Conversations.Slots[slot-1]
+				s.save()
 			}
 		case tea.KeyShiftRight:
-			if s.ConvSelection+1 < len(s.Conversations) {
-				t := s.Conversations[s.ConvSelection+1]
-				s.Conversations[s.ConvSelection+1] = s.Conversations[s.ConvSelection]
-				s.Conversations[s.ConvSelection] = t
-				s.ConvSelection++
-				c = &s.Conversations[s.ConvSelection]
-				s.Conversations.SaveConversations()
-				s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width - 6))
+			if slot := s.Conversations.SlotOf(c); slot != -1 && slot+1 < SlotCount {
+				s.Conversations.Slots[slot+1], s.Conversations.Slots[slot] = s.Conversations.Slots[slot], s.Conversations.Slots[slot+1]
+				s.save()
 			}
 		case tea.KeyF12:
-			s.Conversations.InitConversation(s.ConvSelection)
-			s.Conversations.SaveConversations()
+			c.Clear()
+			s.save()
+			s.refreshViewport()
+		case tea.KeyCtrlL:
+			s.openBrowser()
+			return s, nil
+		case tea.KeyCtrlF:
+			return s, s.openSearch()
+		case tea.KeyCtrlG:
+			return s, s.openPicker()
+		case tea.KeyCtrlP:
+			s.cyclePersona(c)
+			s.save()
+		case tea.KeyCtrlR:
+			s.RawView = !s.RawView
+			s.refreshViewport()
+		case tea.KeyCtrlS:
+			s.startSelection()
+		case tea.KeyCtrlO:
+			s.exporting = true
+			s.Textarea.Placeholder = "Export conversation as (m)arkdown, (h)tml or (j)son, any other key cancels"
+			return s, nil
+		case tea.KeyCtrlY:
+			if answer, ok := c.Messages.lastAnswer(); ok {
+				return s, copyCmd(answer.Content, "last response")
+			}
+			s.notice = "No response to copy"
 		default:
 			c.RecentPrompt(0)
 			var command tea.Cmd
@@ -195,22 +329,233 @@ func (s *State) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return s, nil
 	}
 
-	s.Textarea.Placeholder = "Ask the LLM... (" + s.Config.Model + ")" +
+	s.updatePlaceholder()
+	return s, next
+	//return s, tea.Batch(tiCmd, vpCmd)
+}
+
+// estimate is what asking text in c is expected to cost
+func (s *State) estimate(c *Conversation, text string) cmd.Estimate {
+	return cmd.EstimateCost(s.models, s.requestPrompt(c), text, c.Messages.Context())
+}
+
+// checkBudget holds back a prompt that may exceed a budget, unless it was already held back once
+func (s *State) checkBudget(c *Conversation, text string) error {
+	if s.Config.OverBudget || s.overBudget == text {
+		s.overBudget = ""
+		return nil
+	}
+	// Other windows may have spent since the last answer
+	if spent, err := cmd.SpentToday(); err == nil {
+		s.spentToday = spent
+	}
+	if err := s.Config.Budget.Check(s.estimate(c, text), s.CoinUsage, s.spentToday); err != nil {
+		s.overBudget = text
+		return err
+	}
+	s.overBudget = ""
+	return nil
+}
+
+var overBudgetStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+// inputHint is the line above the textarea: the slash command being typed, or what the prompt may cost
+func (s *State) inputHint() string {
+	if hint := s.slashHint(); hint != "" {
+		return hint
+	}
+	text := s.Textarea.Value()
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	if strings.HasPrefix(text, "//") {
+		text = text[1:]
+	}
+	estimate := s.estimate(s.Current, text)
+	hint := estimate.String()
+	if err := s.Config.Budget.Check(estimate, s.CoinUsage, s.spentToday); err != nil && !s.Config.OverBudget {
+		if s.overBudget == text {
+			hint = "Press Enter again to send anyway, " + err.Error()
+		} else {
+			hint += ", over the " + err.(*cmd.BudgetError).Limit + " budget"
+		}
+		return overBudgetStyle.MaxWidth(s.hintWidth()).Render(hint)
+	}
+	return slashHintStyle.MaxWidth(s.hintWidth()).Render(hint)
+}
+
+// updatePlaceholder shows the status, or the notice, in the empty textarea
+func (s *State) updatePlaceholder() {
+	s.Textarea.Placeholder = "Ask the LLM... (" + s.modelLabel() + ")" +
 		" " + "(%" + strconv.Itoa(int(s.Viewport.ScrollPercent()*100)) + ")" +
-		" " + "(" + strconv.Itoa(s.ConvSelection+1) + ")" +
-		" " + "(" + strconv.FormatFloat(s.CoinUsage, 'f', 2, 64) + ")"
-	if len(c.Messages) == 0 {
-		s.Viewport.SetContent(`Welcome to Straico Cli!
-Type a message and press Enter to send.
+		" " + "(" + s.conversationLabel() + ")" +
+		" " + "(" + strconv.FormatFloat(s.CoinUsage, 'f', 2, 64) + ")" +
+		s.balanceLabel() +
+		s.profileLabel()
+	if s.notice != "" {
+		s.Textarea.Placeholder = s.notice
+	}
+}
+
+// send asks the LLM userMessage in c, with the messages before it as context
+func (s *State) send(c *Conversation, userMessage string) tea.Cmd {
+	context := c.Messages.Context()
+	c.Messages = append(c.Messages, Message{Role: RoleUser, Content: userMessage, Timestamp: time.Now()})
+	s.refreshViewport()
+	s.Textarea.Reset()
+	s.Viewport.GotoBottom()
+	s.Textarea.Placeholder = "Loading..."
+
+	c.Updated = time.Now()
+	p, key := s.requestPrompt(c), s.Config.Key
+	return func() tea.Msg {
+		response, err := p.Request(key, userMessage, context)
+		if err != nil {
+			return LLMResponseMsg{conversation: c, err: err}
+		}
+		result := prompt.NewResult(response, p.Model)
+		if len(result.Models) == 0 {
+			return LLMResponseMsg{conversation: c, err: errors.New("no completions returned")}
+		}
+		return LLMResponseMsg{conversation: c, result: result}
+	}
+}
 
+// requestPrompt is the prompt sent for c, with its persona and model applied
+func (s *State) requestPrompt(c *Conversation) prompt.Prompt {
+	p := s.Config.Prompt
+	if c.Persona != "" {
+		if persona, err := s.Config.FindPersona(c.Persona); err == nil {
+			p = persona.Apply(p)
+		}
+	}
+	if c.Model != "" {
+		p.Model = []string{c.Model}
+	}
+	// The context window follows the models this conversation sends to
+	if wordLimit, maxOutput := cmd.ContextLimits(s.models, p.Model); wordLimit > 0 {
+		p.WordLimit, p.MaxOutput = wordLimit, maxOutput
+	}
+	return p
+}
 
-Use ↑/↓ arrows to scroll through chat history.`)
+// cyclePersona binds c to the next persona from the config, wrapping back to none
+func (s *State) cyclePersona(c *Conversation) {
+	next := 0
+	for i, p := range s.Config.Personas {
+		if p.Name == c.Persona {
+			next = i + 1
+		}
 	}
+	if next < len(s.Config.Personas) {
+		c.Persona = s.Config.Personas[next].Name
+	} else {
+		c.Persona = ""
+	}
+	c.Updated = time.Now()
+}
 
-	return s, nil
-	//return s, tea.Batch(tiCmd, vpCmd)
+// modelLabel names the models, and persona if any, the current conversation sends to
+func (s *State) modelLabel() string {
+	c := s.Current
+	label := strings.Join(s.requestPrompt(c).Model, ", ")
+	if c.Persona != "" {
+		label += " as " + c.Persona
+	}
+	return label
+}
+
+// updateBalance shows the coins left, warning once when they fall below low_balance
+func (s *State) updateBalance(coins float64) {
+	s.balance, s.balanceKnown = coins, true
+	if !s.Config.LowBalance(coins) {
+		s.balanceWarned = false
+		return
+	}
+	if !s.balanceWarned {
+		s.balanceWarned = true
+		if coins <= 0 {
+			s.notice = "The account is out of coins"
+		} else {
+			s.notice = "Low balance: " + strconv.FormatFloat(coins, 'f', 2, 64) + " coins left"
+		}
+	}
+}
+
+// balanceLabel tells the coins left on the account, once known
+func (s *State) balanceLabel() string {
+	if !s.balanceKnown {
+		return ""
+	}
+	label := strconv.FormatFloat(s.balance, 'f', 2, 64) + " left"
+	if s.Config.LowBalance(s.balance) {
+		label = "low balance, " + label
+	}
+	return " (" + label + ")"
+}
+
+// profileLabel names the config profile in use, if any
+func (s *State) profileLabel() string {
+	if s.Config.ActiveProfile == "" {
+		return ""
+	}
+	return " [" + s.Config.ActiveProfile + "]"
+}
+
+// conversationLabel names the current conversation, with its quick slot
+func (s *State) conversationLabel() string {
+	if slot := s.Conversations.SlotOf(s.Current); slot != -1 {
+		return "F" + strconv.Itoa(slot+1) + " " + s.Current.Title()
+	}
+	return s.Current.Title()
+}
+
+// save writes the conversations, problems and conflicts with other windows are shown in the status line
+func (s *State) save() {
+	if err := s.Conversations.SaveConversations(); err != nil {
+		s.Err = err
+		s.notice = err.Error()
+	}
+}
+
+// open shows conv
+func (s *State) open(conv *Conversation) {
+	s.Current = conv
+	s.Selecting = false
+	s.refreshViewport()
+	s.Viewport.GotoBottom()
+}
+
+// refreshViewport renders the selected conversation into the viewport
+func (s *State) refreshViewport() {
+	if s.Selecting {
+		s.renderSelection(s.Viewport.Width - 6)
+		return
+	}
+	c := s.Current
+	if len(c.Messages) == 0 {
+		s.Viewport.SetContent(welcome)
+		return
+	}
+	s.Viewport.SetContent(c.Messages.Render(s.Viewport.Width-6, s.SenderStyle, s.RawView))
 }
 
 func (s State) View() string {
+	if s.browser.open {
+		return s.browserView() + gap + s.browserStatus()
+	}
+	if s.search.open {
+		return s.searchView() + gap + s.searchStatus()
+	}
+	if s.picker.open {
+		return s.pickerView() + gap + s.pickerStatus()
+	}
+	if s.Selecting {
+		return s.Viewport.View() + gap + s.selectionStatus()
+	}
+	// The hint takes the blank line between the viewport and the textarea
+	if hint := s.inputHint(); hint != "" {
+		return s.Viewport.View() + "\n" + hint + "\n" + s.Textarea.View()
+	}
 	return s.Viewport.View() + gap + s.Textarea.View()
 }
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestCheckBudget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := &State{Conversations: &Conversations{}, Textarea: textarea.New()}
+	s.Current = s.Conversations.Slot(0)
+	s.Config.Prompt = prompt.Prompt{Model: []string{"anthropic/claude-sonnet-4"}}
+	s.Config.Budget = cmd.Budget{Request: 1}
+	s.models = []cmd.Models{{Id: "anthropic/claude-sonnet-4", Pricing: 5, PricingWords: 100}}
+
+	cheap := "a short question"
+	if err := s.checkBudget(s.Current, cheap); err != nil {
+		t.Errorf("Expected a cheap prompt to be sent, got %v", err)
+	}
+
+	expensive := strings.Repeat("word ", 40)
+	if err := s.checkBudget(s.Current, expensive); err == nil {
+		t.Fatal("Expected an expensive prompt to be held back")
+	}
+	s.Textarea.SetValue(expensive)
+	if hint := s.inputHint(); !strings.Contains(hint, "Press Enter again") {
+		t.Errorf("Expected the hint to ask for confirmation, got %q", hint)
+	}
+	if err := s.checkBudget(s.Current, expensive); err != nil {
+		t.Errorf("Expected the second Enter to send it, got %v", err)
+	}
+
+	s.Textarea.SetValue("hello")
+	if hint := s.inputHint(); !strings.Contains(hint, "≈ 0.05 coins for 1 words") {
+		t.Errorf("Expected the estimate while typing, got %q", hint)
+	}
+}
+
+func TestUpdateBalance(t *testing.T) {
+	s := &State{}
+	s.Config.BalanceWarning = 100
+	if s.balanceLabel() != "" {
+		t.Errorf("Expected no balance before it is known, got %q", s.balanceLabel())
+	}
+
+	s.updateBalance(500)
+	if s.balanceLabel() != " (500.00 left)" || s.notice != "" {
+		t.Errorf("Expected the balance without a warning, got %q and %q", s.balanceLabel(), s.notice)
+	}
+	s.updateBalance(80)
+	if !strings.Contains(s.balanceLabel(), "low balance, 80.00 left") || s.notice != "Low balance: 80.00 coins left" {
+		t.Errorf("Expected a low balance warning, got %q and %q", s.balanceLabel(), s.notice)
+	}
+	s.notice = ""
+	s.updateBalance(60)
+	if s.notice != "" {
+		t.Errorf("Expected to be warned only once, got %q", s.notice)
+	}
+}
+
+func TestUpdateCyclePersonaKeepsCursor(t *testing.T) {
+	s := slashState(t)
+	s.Textarea.Focus()
+	s.Textarea.SetValue("first line\nsecond line")
+
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+
+	if s.Current.Persona != "reviewer" {
+		t.Errorf("Expected ctrl+p to select the first persona, got %q", s.Current.Persona)
+	}
+	if s.Textarea.Line() != 1 {
+		t.Errorf("Expected the cursor to stay on the second line, got line %d", s.Textarea.Line())
+	}
+}
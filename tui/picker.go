@@ -0,0 +1,221 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+)
+
+// pickerChoice is a line of the model picker, an empty model stands for the configured models
+type pickerChoice struct {
+	model cmd.Models
+	// matched are the byte offsets of the matching characters in the model's id and name
+	matched []int
+}
+
+// modelPicker chooses the model of the current conversation, fuzzy matching the catalog as the query is typed
+type modelPicker struct {
+	open    bool
+	input   textinput.Model
+	choices []pickerChoice
+	cursor  int
+}
+
+// pickerSource matches against a model's id followed by its name
+type pickerSource []cmd.Models
+
+func (p pickerSource) String(i int) string { return p[i].Id + " " + p[i].Name }
+func (p pickerSource) Len() int            { return len(p) }
+
+var pickerDetailStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+// openPicker shows the model picker with the current conversation's model selected
+func (s *State) openPicker() tea.Cmd {
+	s.picker = modelPicker{open: true, input: textinput.New()}
+	s.picker.input.Prompt = "Model: "
+	s.picker.input.Focus()
+	s.filterPicker()
+	for i, choice := range s.picker.choices {
+		if choice.model.Id == s.Current.Model {
+			s.picker.cursor = i
+		}
+	}
+	return textinput.Blink
+}
+
+// filterPicker lists the models matching the query, best match first.
+// Without a query the configured models come first, then the catalog in the API's order.
+func (s *State) filterPicker() {
+	picker := &s.picker
+	query := strings.TrimSpace(picker.input.Value())
+	picker.cursor = 0
+	if query == "" {
+		picker.choices = []pickerChoice{{}}
+		for _, m := range s.models {
+			picker.choices = append(picker.choices, pickerChoice{model: m})
+		}
+		return
+	}
+	picker.choices = nil
+	for _, match := range fuzzy.FindFrom(query, pickerSource(s.models)) {
+		picker.choices = append(picker.choices, pickerChoice{model: s.models[match.Index], matched: match.MatchedIndexes})
+	}
+}
+
+// updatePicker handles input while picking a model, other messages are handled as usual
+func (s *State) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		s.picker.open = false
+		model, cmd := s.Update(msg)
+		s.picker.open = true
+		return model, cmd
+	}
+
+	picker := &s.picker
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return s, tea.Quit
+	case "esc", "ctrl+g":
+		picker.open = false
+		return s, nil
+	case "up", "ctrl+p":
+		if picker.cursor > 0 {
+			picker.cursor--
+		}
+		return s, nil
+	case "down", "ctrl+n":
+		if picker.cursor < len(picker.choices)-1 {
+			picker.cursor++
+		}
+		return s, nil
+	case "enter":
+		typed := strings.TrimSpace(picker.input.Value())
+		switch {
+		case len(picker.choices) > 0:
+			s.pickModel(picker.choices[picker.cursor].model.Id)
+		case len(s.models) == 0 && typed != "":
+			// Without the catalog the typed id is trusted, the API tells if it is wrong
+			s.pickModel(typed)
+		}
+		return s, nil
+	}
+
+	query := picker.input.Value()
+	var cmd tea.Cmd
+	picker.input, cmd = picker.input.Update(keyMsg)
+	if picker.input.Value() != query {
+		s.filterPicker()
+	}
+	return s, cmd
+}
+
+// pickModel binds the current conversation to model, or to the configured models when it is empty
+func (s *State) pickModel(model string) {
+	c := s.Current
+	s.picker.open = false
+	c.Model = model
+	c.Updated = time.Now()
+	s.notice = ""
+	s.save()
+	if s.notice == "" {
+		if model == "" {
+			s.notice = c.Title() + " now uses the configured models: " + s.modelLabel()
+		} else {
+			s.notice = c.Title() + " now uses " + model
+		}
+	}
+	s.Textarea.Placeholder = s.notice
+}
+
+// Line renders the choice, its matching characters in style
+func (p pickerChoice) Line(style lipgloss.Style) string {
+	m := p.model
+	if m.Id == "" {
+		return "default (the configured models)"
+	}
+	label := highlightBytes(m.Id+" "+m.Name, p.matched, style)
+	var details []string
+	if m.PricingWords > 0 {
+		details = append(details, strconv.FormatFloat(m.Pricing, 'f', -1, 64)+" coins / "+strconv.FormatInt(m.PricingWords, 10)+" words")
+	}
+	if m.WordLimit > 0 {
+		details = append(details, strconv.FormatInt(m.WordLimit, 10)+" words of context")
+	}
+	if len(details) == 0 {
+		return label
+	}
+	return label + "  " + pickerDetailStyle.Render(strings.Join(details, ", "))
+}
+
+// highlightBytes renders the characters of text starting at the given byte offsets in style
+func highlightBytes(text string, offsets []int, style lipgloss.Style) string {
+	if len(offsets) == 0 {
+		return text
+	}
+	marked := make(map[int]bool, len(offsets))
+	for _, o := range offsets {
+		marked[o] = true
+	}
+	var b strings.Builder
+	for i, r := range text {
+		if marked[i] {
+			b.WriteString(style.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pickerView replaces the viewport while picking a model
+func (s State) pickerView() string {
+	picker := s.picker
+	width := s.Viewport.Width - s.Viewport.Style.GetHorizontalFrameSize()
+	height := s.Viewport.Height
+	if height < 1 {
+		height = 1
+	}
+
+	start := 0
+	if picker.cursor >= height {
+		start = picker.cursor - height + 1
+	}
+
+	lines := make([]string, 0, height)
+	for i := start; i < len(picker.choices) && len(lines) < height; i++ {
+		choice := picker.choices[i]
+		line := choice.Line(matchStyle)
+		if choice.model.Id != "" && choice.model.Id == s.Current.Model {
+			line += s.SenderStyle.Render("  (current)")
+		}
+		line = lipgloss.NewStyle().MaxWidth(width - 2).Render(line)
+		if i == picker.cursor {
+			line = browserCursorStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	switch {
+	case s.modelsErr != nil:
+		lines = append(lines, "Unable to load the models: "+s.modelsErr.Error()+", type a model id and press enter")
+	case s.models == nil:
+		lines = append(lines, "Loading models...")
+	case len(picker.choices) == 0:
+		lines = append(lines, "No matching models")
+	}
+	return s.Viewport.Style.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// pickerStatus replaces the textarea while picking a model
+func (s State) pickerStatus() string {
+	status := s.picker.input.View() + "  (for " + s.conversationLabel() + ", ↑/↓ move, enter choose, esc close)"
+	return lipgloss.NewStyle().Height(s.Textarea.Height()).Render(s.Textarea.Prompt + status)
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestUpdatePicker(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := &State{Conversations: &Conversations{}}
+	s.Config.Prompt = prompt.Prompt{Model: []string{"openai/gpt-4.1-mini"}}
+	s.models = []cmd.Models{
+		{Id: "openai/gpt-4.1-mini", Name: "GPT-4.1 Mini", Pricing: 1, PricingWords: 100, WordLimit: 500000},
+		{Id: "anthropic/claude-sonnet-4", Name: "Claude Sonnet 4", Pricing: 5, PricingWords: 100, WordLimit: 150000},
+	}
+	first := s.Conversations.Slot(0)
+	second := s.Conversations.Slot(1)
+	s.Current = first
+
+	s.openPicker()
+	if len(s.picker.choices) != 3 {
+		t.Fatalf("Expected the default and 2 models, got %d choices", len(s.picker.choices))
+	}
+	s.updatePicker(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("claude")})
+	if len(s.picker.choices) != 1 || s.picker.choices[0].model.Id != "anthropic/claude-sonnet-4" {
+		t.Fatalf("Expected only claude to match, got %+v", s.picker.choices)
+	}
+	s.updatePicker(tea.KeyMsg{Type: tea.KeyEnter})
+	if s.picker.open || first.Model != "anthropic/claude-sonnet-4" {
+		t.Errorf("Expected enter to bind the conversation to claude, got %q", first.Model)
+	}
+
+	if got := s.requestPrompt(first); len(got.Model) != 1 || got.Model[0] != "anthropic/claude-sonnet-4" || got.WordLimit != 150000 {
+		t.Errorf("Expected claude and its context window for the first conversation, got %v %d", got.Model, got.WordLimit)
+	}
+	if got := s.requestPrompt(second); got.Model[0] != "openai/gpt-4.1-mini" || got.WordLimit != 500000 {
+		t.Errorf("Expected the configured model for the second conversation, got %v %d", got.Model, got.WordLimit)
+	}
+
+	// The first choice goes back to the configured models
+	s.openPicker()
+	if s.picker.cursor != 2 {
+		t.Errorf("Expected the cursor on the current model, got %d", s.picker.cursor)
+	}
+	s.updatePicker(tea.KeyMsg{Type: tea.KeyUp})
+	s.updatePicker(tea.KeyMsg{Type: tea.KeyUp})
+	s.updatePicker(tea.KeyMsg{Type: tea.KeyEnter})
+	if first.Model != "" {
+		t.Errorf("Expected the binding to be cleared, got %q", first.Model)
+	}
+}
+
+func TestPickerChoiceLine(t *testing.T) {
+	choice := pickerChoice{
+		model:   cmd.Models{Id: "openai/gpt-4.1", Name: "GPT-4.1", Pricing: 0.5, PricingWords: 100, WordLimit: 800000},
+		matched: []int{7, 8, 9},
+	}
+	line := choice.Line(lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" }))
+	if !strings.HasPrefix(line, "openai/[g][p][t]-4.1 GPT-4.1") {
+		t.Errorf("Expected the matched characters to be highlighted, got %q", line)
+	}
+	if !strings.Contains(line, "0.5 coins / 100 words") || !strings.Contains(line, "800000 words of context") {
+		t.Errorf("Expected the price and context size, got %q", line)
+	}
+}
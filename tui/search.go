@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// snippetLength is the number of characters shown around a match
+const snippetLength = 80
+
+// SearchHit is a message, or a prompt from the history, matching a search
+type SearchHit struct {
+	Conversation *Conversation
+	// Message is the index of the matching message, -1 for a prompt only found in the history
+	Message int
+	Role    string
+	Time    time.Time
+	Snippet string
+	// matches are the highlighted rune ranges of Snippet
+	matches [][2]int
+	score   int
+}
+
+// Search finds every message and prompt containing all words of query.
+// Hits with more matches, or the exact phrase, come first, then the most recent.
+func (c *Conversations) Search(query string) []SearchHit {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	phrase := fold(strings.Join(strings.Fields(query), " "))
+
+	var hits []SearchHit
+	for _, conv := range c.List {
+		asked := make(map[string]bool)
+		for i, m := range conv.Messages {
+			if m.Role == RoleUser {
+				asked[m.Content] = true
+			}
+			when := m.Timestamp
+			if when.IsZero() {
+				when = conv.Updated
+			}
+			if hit, ok := match(m.Content, terms, phrase); ok {
+				hit.Conversation, hit.Message, hit.Role, hit.Time = conv, i, m.Role, when
+				hits = append(hits, hit)
+			}
+		}
+		// Prompts are also messages, unless the conversation was cleared
+		for _, p := range conv.PromptHistory {
+			if asked[p] {
+				continue
+			}
+			asked[p] = true
+			if hit, ok := match(p, terms, phrase); ok {
+				hit.Conversation, hit.Message, hit.Role, hit.Time = conv, -1, RoleUser, conv.Updated
+				hits = append(hits, hit)
+			}
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].Time.After(hits[j].Time)
+	})
+	return hits
+}
+
+// Highlight returns the snippet with the matching words rendered in style
+func (h SearchHit) Highlight(style lipgloss.Style) string {
+	snippet := []rune(h.Snippet)
+	var b strings.Builder
+	last := 0
+	for _, m := range h.matches {
+		b.WriteString(string(snippet[last:m[0]]))
+		b.WriteString(style.Render(string(snippet[m[0]:m[1]])))
+		last = m[1]
+	}
+	b.WriteString(string(snippet[last:]))
+	return b.String()
+}
+
+// searchTerms are the distinct, case folded words of query
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(query) {
+		term := fold(word)
+		if !seen[string(term)] {
+			seen[string(term)] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// fold lower cases s rune by rune, so indexes match the original text
+func fold(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// match scores text against the terms, and cuts a snippet around the first match
+func match(content string, terms [][]rune, phrase []rune) (SearchHit, bool) {
+	text := []rune(strings.Join(strings.Fields(content), " "))
+	folded := fold(string(text))
+
+	var hit SearchHit
+	first := len(text)
+	var ranges [][2]int
+	for _, term := range terms {
+		found := indexAll(folded, term)
+		if len(found) == 0 {
+			return SearchHit{}, false
+		}
+		hit.score += len(found)
+		first = min(first, found[0])
+		for _, start := range found {
+			ranges = append(ranges, [2]int{start, start + len(term)})
+		}
+	}
+	if len(terms) > 1 && len(indexAll(folded, phrase)) > 0 {
+		hit.score += 10
+	}
+
+	start := max(0, first-snippetLength/4)
+	end := min(len(text), start+snippetLength)
+	hit.Snippet = string(text[start:end])
+	offset := 0
+	if start > 0 {
+		hit.Snippet = "…" + hit.Snippet
+		offset = 1
+	}
+	if end < len(text) {
+		hit.Snippet += "…"
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	for _, r := range ranges {
+		if r[0] < start || r[1] > end {
+			continue
+		}
+		r = [2]int{r[0] - start + offset, r[1] - start + offset}
+		// Overlapping words are merged into one highlight
+		if n := len(hit.matches); n > 0 && r[0] <= hit.matches[n-1][1] {
+			hit.matches[n-1][1] = max(hit.matches[n-1][1], r[1])
+			continue
+		}
+		hit.matches = append(hit.matches, r)
+	}
+	return hit, true
+}
+
+// indexAll returns the start of every non overlapping occurrence of term in text
+func indexAll(text, term []rune) []int {
+	var found []int
+	for i := 0; i+len(term) <= len(text); i++ {
+		if string(text[i:i+len(term)]) == string(term) {
+			found = append(found, i)
+			i += len(term) - 1
+		}
+	}
+	return found
+}
+
+// searchOverlay searches every conversation as the query is typed
+type searchOverlay struct {
+	open   bool
+	input  textinput.Model
+	hits   []SearchHit
+	cursor int
+}
+
+var matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+
+// openSearch shows the search overlay with an empty query
+func (s *State) openSearch() tea.Cmd {
+	s.search = searchOverlay{open: true, input: textinput.New()}
+	s.search.input.Prompt = "Search: "
+	s.search.input.Focus()
+	return textinput.Blink
+}
+
+// updateSearch handles input while searching, other messages are handled as usual
+func (s *State) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		s.search.open = false
+		model, cmd := s.Update(msg)
+		s.search.open = true
+		return model, cmd
+	}
+
+	search := &s.search
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return s, tea.Quit
+	case "esc", "ctrl+f":
+		search.open = false
+		return s, nil
+	case "up", "ctrl+p":
+		if search.cursor > 0 {
+			search.cursor--
+		}
+		return s, nil
+	case "down", "ctrl+n":
+		if search.cursor < len(search.hits)-1 {
+			search.cursor++
+		}
+		return s, nil
+	case "enter":
+		if len(search.hits) > 0 {
+			search.open = false
+			s.jumpTo(search.hits[search.cursor])
+		}
+		return s, nil
+	}
+
+	query := search.input.Value()
+	var cmd tea.Cmd
+	search.input, cmd = search.input.Update(keyMsg)
+	if search.input.Value() != query {
+		search.hits = s.Conversations.Search(search.input.Value())
+		search.cursor = 0
+	}
+	return s, cmd
+}
+
+// jumpTo opens the conversation of hit, scrolled to the matching message
+func (s *State) jumpTo(hit SearchHit) {
+	s.open(hit.Conversation)
+	if hit.Message < 0 {
+		return
+	}
+	offset := 0
+	for _, b := range s.Current.Messages.renderBlocks(s.Viewport.Width-6, s.SenderStyle, s.RawView) {
+		if b.first <= hit.Message && hit.Message <= b.last {
+			break
+		}
+		offset += lipgloss.Height(b.text)
+	}
+	s.Viewport.SetYOffset(offset)
+}
+
+// searchView replaces the viewport while searching
+func (s State) searchView() string {
+	search := s.search
+	width := s.Viewport.Width - s.Viewport.Style.GetHorizontalFrameSize()
+	height := s.Viewport.Height
+	if height < 1 {
+		height = 1
+	}
+
+	start := 0
+	if search.cursor >= height {
+		start = search.cursor - height + 1
+	}
+
+	lines := make([]string, 0, height)
+	for i := start; i < len(search.hits) && len(lines) < height; i++ {
+		hit := search.hits[i]
+		label := hit.Conversation.Title()
+		if n := s.Conversations.SlotOf(hit.Conversation); n != -1 {
+			label = "F" + strconv.Itoa(n+1) + " " + label
+		}
+		sender := "You: "
+		if hit.Role == RoleAssistant {
+			sender = "LLM: "
+		}
+		line := s.SenderStyle.Render(label) + "  " + sender + hit.Highlight(matchStyle)
+		line = lipgloss.NewStyle().MaxWidth(width - 2).Render(line)
+		if i == search.cursor {
+			line = browserCursorStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	switch {
+	case search.input.Value() == "":
+		lines = append(lines, "Type to search every conversation")
+	case len(search.hits) == 0:
+		lines = append(lines, "No matches")
+	}
+	return s.Viewport.Style.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// searchStatus replaces the textarea while searching
+func (s State) searchStatus() string {
+	status := s.search.input.View() + "  (" + strconv.Itoa(len(s.search.hits)) + " matches, ↑/↓ move, enter open, esc close)"
+	return lipgloss.NewStyle().Height(s.Textarea.Height()).Render(s.Textarea.Prompt + status)
+}
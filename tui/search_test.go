@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestConversationsSearch(t *testing.T) {
+	conversations := &Conversations{}
+	old := conversations.Add()
+	old.Messages = Messages{
+		{Role: RoleUser, Content: "How do I write a regex for dates?", Timestamp: time.Now().Add(-time.Hour)},
+		{Role: RoleAssistant, Content: "Use the regex `\\d{4}-\\d{2}-\\d{2}`, a Regex is fine for dates.", Timestamp: time.Now().Add(-time.Hour)},
+	}
+	recent := conversations.Add()
+	recent.Messages = Messages{{Role: RoleUser, Content: "Another REGEX question", Timestamp: time.Now()}}
+	recent.PromptHistory = []string{"Another REGEX question", "regex asked before clearing"}
+
+	hits := conversations.Search("regex")
+	if len(hits) != 4 {
+		t.Fatalf("Expected 4 hits, got %d", len(hits))
+	}
+	if hits[0].Conversation != old || hits[0].Message != 1 {
+		t.Errorf("Expected the answer with the most matches first, got %+v", hits[0])
+	}
+	if hits[1].Conversation != recent || hits[1].Message != 0 {
+		t.Errorf("Expected the most recent single match next, got %+v", hits[1])
+	}
+	prompts := 0
+	for _, hit := range hits {
+		if hit.Message == -1 {
+			prompts++
+			if hit.Snippet != "regex asked before clearing" {
+				t.Errorf("Expected only the cleared prompt from the history, got %q", hit.Snippet)
+			}
+		}
+	}
+	if prompts != 1 {
+		t.Errorf("Expected 1 hit from the prompt history, got %d", prompts)
+	}
+
+	if hits := conversations.Search("regex dates"); len(hits) != 2 {
+		t.Errorf("Expected every word to be required, got %d hits", len(hits))
+	}
+	if hits := conversations.Search("  "); hits != nil {
+		t.Errorf("Expected no hits for an empty query, got %d", len(hits))
+	}
+}
+
+func TestSearchHitHighlight(t *testing.T) {
+	hit, ok := match(strings.Repeat("padding ", 20)+"the Regex\nanswer", searchTerms("regex"), fold("regex"))
+	if !ok {
+		t.Fatal("Expected a match")
+	}
+	if !strings.HasPrefix(hit.Snippet, "…") || !strings.HasSuffix(hit.Snippet, "the Regex answer") {
+		t.Errorf("Expected a snippet around the match, got %q", hit.Snippet)
+	}
+
+	marked := hit.Highlight(lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" }))
+	if !strings.HasSuffix(marked, "the [Regex] answer") {
+		t.Errorf("Expected the match to be highlighted, got %q", marked)
+	}
+}
+
+func TestUpdateSearch(t *testing.T) {
+	s := &State{Conversations: &Conversations{}}
+	s.Current = s.Conversations.Slot(0)
+	other := s.Conversations.Add()
+	other.Messages = Messages{{Role: RoleUser, Content: "find me"}}
+
+	s.openSearch()
+	s.updateSearch(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("find")})
+	if len(s.search.hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(s.search.hits))
+	}
+
+	s.updateSearch(tea.KeyMsg{Type: tea.KeyEnter})
+	if s.search.open || s.Current != other {
+		t.Error("Expected enter to open the matching conversation")
+	}
+}
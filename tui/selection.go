@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// codeBlock is a fenced code block found in a message
+type codeBlock struct {
+	Lang string
+	Code string
+}
+
+// codeBlocks returns the ``` and ~~~ fenced blocks in content, an unclosed fence runs to the end
+func codeBlocks(content string) []codeBlock {
+	var blocks []codeBlock
+	var fence string
+	var current codeBlock
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				current = codeBlock{Lang: strings.TrimSpace(trimmed[3:])}
+				lines = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, current)
+			fence = ""
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if fence != "" {
+		current.Code = strings.Join(lines, "\n")
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// selectionItem is a message, or one of its code blocks, that can be copied
+type selectionItem struct {
+	message int
+	// code is the index of the code block, -1 selects the whole message
+	code int
+}
+
+func (m Messages) selectionItems() []selectionItem {
+	var items []selectionItem
+	for i, msg := range m {
+		items = append(items, selectionItem{message: i, code: -1})
+		if msg.Role == RoleAssistant && !msg.Error {
+			for j := range codeBlocks(msg.Content) {
+				items = append(items, selectionItem{message: i, code: j})
+			}
+		}
+	}
+	return items
+}
+
+// text is what gets copied for item
+func (m Messages) text(item selectionItem) string {
+	content := m[item.message].Content
+	if item.code < 0 {
+		return content
+	}
+	return codeBlocks(content)[item.code].Code
+}
+
+// describe names item for the status line
+func (m Messages) describe(item selectionItem) string {
+	label := "message " + strconv.Itoa(item.message+1) + "/" + strconv.Itoa(len(m))
+	if item.code < 0 {
+		return label
+	}
+	block := codeBlocks(m[item.message].Content)[item.code]
+	description := "code block " + strconv.Itoa(item.code+1)
+	if block.Lang != "" {
+		description += " (" + block.Lang + ")"
+	}
+	return description + " in " + label
+}
+
+// lastAnswer returns the most recent successful answer
+func (m Messages) lastAnswer() (Message, bool) {
+	for i := len(m) - 1; i >= 0; i-- {
+		if m[i].Role == RoleAssistant && !m[i].Error {
+			return m[i], true
+		}
+	}
+	return Message{}, false
+}
+
+var selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+
+// startSelection enters selection mode on the most recent item
+func (s *State) startSelection() {
+	items := s.Current.Messages.selectionItems()
+	if len(items) == 0 {
+		s.notice = "Nothing to select"
+		return
+	}
+	s.Selecting = true
+	s.selection = len(items) - 1
+	s.refreshViewport()
+}
+
+// updateSelection handles keys while selecting, the textarea does not see them
+func (s *State) updateSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	messages := s.Current.Messages
+	items := messages.selectionItems()
+	if s.selection >= len(items) {
+		s.selection = len(items) - 1
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return s, tea.Quit
+	case "up", "k", "shift+tab":
+		if s.selection > 0 {
+			s.selection--
+		}
+	case "down", "j", "tab":
+		if s.selection < len(items)-1 {
+			s.selection++
+		}
+	case "enter", "y":
+		item := items[s.selection]
+		s.Selecting = false
+		s.refreshViewport()
+		return s, copyCmd(messages.text(item), messages.describe(item))
+	case "esc", "q", "ctrl+s":
+		s.Selecting = false
+	}
+	s.refreshViewport()
+	return s, nil
+}
+
+// renderSelection renders the conversation with a marker beside the selected message,
+// and scrolls it into view
+func (s *State) renderSelection(width int) {
+	messages := s.Current.Messages
+	items := messages.selectionItems()
+	if len(items) == 0 {
+		s.Selecting = false
+		return
+	}
+	selected := items[s.selection].message
+
+	offset := 0
+	var lines []string
+	for _, b := range messages.renderBlocks(width-2, s.SenderStyle, s.RawView) {
+		marker := "  "
+		if b.first <= selected && selected <= b.last {
+			marker = selectedStyle.Render("▌ ")
+			offset = len(lines)
+		}
+		for _, line := range strings.Split(b.text, "\n") {
+			lines = append(lines, marker+line)
+		}
+	}
+	s.Viewport.SetContent(strings.Join(lines, "\n"))
+	s.Viewport.SetYOffset(offset)
+}
+
+// selectionStatus replaces the textarea while selecting
+func (s *State) selectionStatus() string {
+	messages := s.Current.Messages
+	items := messages.selectionItems()
+	status := "Select: ↑/↓ move, enter/y copy, esc back"
+	if s.selection < len(items) {
+		status += " (" + messages.describe(items[s.selection]) + ")"
+	}
+	return lipgloss.NewStyle().Height(s.Textarea.Height()).Render(s.Textarea.Prompt + status)
+}
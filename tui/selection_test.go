@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	content := "Here you go:\n```go\nfmt.Println(\"hi\")\n```\nand\n~~~\nls -la\n~~~\n```python\nprint(1)"
+
+	blocks := codeBlocks(content)
+
+	want := []codeBlock{
+		{Lang: "go", Code: `fmt.Println("hi")`},
+		{Lang: "", Code: "ls -la"},
+		{Lang: "python", Code: "print(1)"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("Expected %d blocks, got %d: %+v", len(want), len(blocks), blocks)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("Block %d: Expected %+v, got %+v", i, want[i], blocks[i])
+		}
+	}
+}
+
+func TestSelectionItems(t *testing.T) {
+	messages := Messages{
+		{Role: RoleUser, Content: "```not code from the user```"},
+		{Role: RoleAssistant, Content: "One:\n```sh\necho 1\n```\nTwo:\n```sh\necho 2\n```"},
+		{Role: RoleAssistant, Content: "```sh\nfailed\n```", Error: true},
+	}
+
+	items := messages.selectionItems()
+
+	if len(items) != 5 {
+		t.Fatalf("Expected 5 items, got %d: %+v", len(items), items)
+	}
+	if got := messages.text(items[3]); got != "echo 2" {
+		t.Errorf("Expected second code block 'echo 2', got %q", got)
+	}
+	if got := messages.describe(items[3]); got != "code block 2 (sh) in message 2/3" {
+		t.Errorf("Unexpected description %q", got)
+	}
+	if got := messages.text(items[1]); got != messages[1].Content {
+		t.Errorf("Expected whole message, got %q", got)
+	}
+}
+
+func TestLastAnswer(t *testing.T) {
+	messages := Messages{
+		{Role: RoleUser, Content: "Question"},
+		{Role: RoleAssistant, Content: "Answer"},
+		{Role: RoleAssistant, Content: "request failed", Error: true},
+	}
+
+	answer, ok := messages.lastAnswer()
+	if !ok || answer.Content != "Answer" {
+		t.Errorf("Expected last answer 'Answer', got %q, %v", answer.Content, ok)
+	}
+
+	if _, ok := (Messages{{Role: RoleUser, Content: "Question"}}).lastAnswer(); ok {
+		t.Error("Expected no answer")
+	}
+}
+
+func TestUpdateSelection(t *testing.T) {
+	s := &State{Current: NewConversation()}
+	s.Current.Messages = Messages{
+		{Role: RoleUser, Content: "Question"},
+		{Role: RoleAssistant, Content: "```go\nx := 1\n```"},
+	}
+
+	s.startSelection()
+	if !s.Selecting || s.selection != 2 {
+		t.Fatalf("Expected selection on last item, got %v %d", s.Selecting, s.selection)
+	}
+
+	s.updateSelection(tea.KeyMsg{Type: tea.KeyUp})
+	s.updateSelection(tea.KeyMsg{Type: tea.KeyUp})
+	s.updateSelection(tea.KeyMsg{Type: tea.KeyUp})
+	if s.selection != 0 {
+		t.Errorf("Expected selection to stop at 0, got %d", s.selection)
+	}
+
+	_, command := s.updateSelection(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if s.Selecting {
+		t.Error("Expected copying to leave selection mode")
+	}
+	if command == nil {
+		t.Error("Expected a copy command")
+	}
+}
@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tyler71/straico-cli/m/v0/export"
+)
+
+// SlashCommand is a command typed in the textarea, e.g. /model openai/gpt-4.1
+type SlashCommand struct {
+	Name string
+	// Args describes the arguments in the hint, e.g. [model]
+	Args    string
+	Summary string
+	// Complete returns the values the argument may take, nil when it is free text
+	Complete func(s *State) []string
+	Run      func(s *State, args string) tea.Cmd
+}
+
+// slashCommands are the registered commands by name
+var slashCommands = make(map[string]SlashCommand)
+
+// RegisterSlashCommand adds a command to the textarea, replacing any with the same name
+func RegisterSlashCommand(command SlashCommand) {
+	slashCommands[command.Name] = command
+}
+
+func init() {
+	RegisterSlashCommand(SlashCommand{Name: "help", Summary: "list the commands", Run: slashHelp})
+	RegisterSlashCommand(SlashCommand{Name: "model", Args: "[model|default]", Summary: "bind the conversation to a model, without one open the picker",
+		Complete: modelChoices, Run: slashModel})
+	RegisterSlashCommand(SlashCommand{Name: "persona", Args: "[persona|none]", Summary: "bind the conversation to a persona, without one cycle through them",
+		Complete: personaChoices, Run: slashPersona})
+	RegisterSlashCommand(SlashCommand{Name: "clear", Summary: "erase the conversation's history", Run: slashClear})
+	RegisterSlashCommand(SlashCommand{Name: "new", Args: "[name]", Summary: "start a new conversation", Run: slashNew})
+	RegisterSlashCommand(SlashCommand{Name: "export", Args: "[markdown|html|json]", Summary: "write the conversation to a file in the current directory",
+		Complete: func(*State) []string { return []string{export.FormatMarkdown, export.FormatHTML, export.FormatJSON} }, Run: slashExport})
+	RegisterSlashCommand(SlashCommand{Name: "attach", Args: "[url|clear]", Summary: "send a file or YouTube url with the next prompts, without one list them",
+		Complete: func(*State) []string { return []string{"clear"} }, Run: slashAttach})
+	RegisterSlashCommand(SlashCommand{Name: "retry", Summary: "ask the last prompt again, replacing its answer", Run: slashRetry})
+	RegisterSlashCommand(SlashCommand{Name: "cost", Summary: "show the coins used by the conversation and the session", Run: slashCost})
+}
+
+// slashLine returns what follows the slash when text is a command, a doubled slash is a message
+func slashLine(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") || strings.HasPrefix(text, "//") {
+		return "", false
+	}
+	return text[1:], true
+}
+
+// runSlash runs the command of line, the text typed after the slash. Its notice, if any, replaces the status.
+func (s *State) runSlash(line string) tea.Cmd {
+	name, args, _ := strings.Cut(line, " ")
+	command, ok := slashCommands[strings.ToLower(name)]
+	if !ok {
+		s.notice = "Unknown command /" + name + ", see /help"
+		s.Textarea.Placeholder = s.notice
+		return nil
+	}
+	s.notice = ""
+	cmd := command.Run(s, strings.TrimSpace(args))
+	if s.notice != "" {
+		s.Textarea.Placeholder = s.notice
+	}
+	return cmd
+}
+
+// slashNames are the registered command names in order
+func slashNames() []string {
+	names := make([]string, 0, len(slashCommands))
+	for name := range slashCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// slashCandidates returns what the word being typed may complete to: command names, or the command's argument values
+func (s *State) slashCandidates() (command *SlashCommand, typed string, candidates []string) {
+	line, ok := slashLine(s.Textarea.Value())
+	if !ok {
+		return nil, "", nil
+	}
+	name, args, hasArgs := strings.Cut(line, " ")
+	if !hasArgs {
+		for _, n := range slashNames() {
+			if strings.HasPrefix(n, strings.ToLower(name)) {
+				candidates = append(candidates, n)
+			}
+		}
+		return nil, name, candidates
+	}
+	found, ok := slashCommands[strings.ToLower(name)]
+	if !ok {
+		return nil, "", nil
+	}
+	args = strings.TrimLeft(args, " ")
+	if found.Complete != nil {
+		for _, value := range found.Complete(s) {
+			if strings.HasPrefix(strings.ToLower(value), strings.ToLower(args)) {
+				candidates = append(candidates, value)
+			}
+		}
+	}
+	return &found, args, candidates
+}
+
+// completeSlash completes the command name or argument being typed, as far as the candidates agree
+func (s *State) completeSlash() {
+	command, typed, candidates := s.slashCandidates()
+	if len(candidates) == 0 {
+		return
+	}
+	completed := commonPrefix(candidates)
+	if len(completed) < len(typed) {
+		return
+	}
+	if command == nil {
+		value := "/" + completed
+		// A complete name is followed by its arguments
+		if len(candidates) == 1 {
+			value += " "
+		}
+		s.Textarea.SetValue(value)
+		return
+	}
+	s.Textarea.SetValue("/" + command.Name + " " + completed)
+}
+
+// commonPrefix is the longest prefix shared by every value
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+var slashHintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+// slashHint describes the command being typed and what tab completes to, empty when no command is typed
+func (s State) slashHint() string {
+	command, _, candidates := s.slashCandidates()
+	if command == nil {
+		if _, ok := slashLine(s.Textarea.Value()); !ok {
+			return ""
+		}
+		if len(candidates) == 0 {
+			return slashHintStyle.Render("Unknown command, see /help")
+		}
+		if len(candidates) == 1 {
+			found := slashCommands[candidates[0]]
+			return slashHintStyle.Render(slashUsage(found) + "  " + found.Summary)
+		}
+		return slashHintStyle.Render("/" + strings.Join(candidates, "  /"))
+	}
+	hint := slashUsage(*command)
+	if len(candidates) > 0 {
+		shown := candidates[:min(len(candidates), 5)]
+		hint += "  " + strings.Join(shown, "  ")
+		if len(candidates) > len(shown) {
+			hint += "  +" + strconv.Itoa(len(candidates)-len(shown)) + " more"
+		}
+	} else {
+		hint += "  " + command.Summary
+	}
+	return slashHintStyle.MaxWidth(s.hintWidth()).Render(hint)
+}
+
+// hintWidth keeps the hint on a single line
+func (s State) hintWidth() int {
+	if s.Viewport.Width < 1 {
+		return 80
+	}
+	return s.Viewport.Width
+}
+
+func slashUsage(command SlashCommand) string {
+	if command.Args == "" {
+		return "/" + command.Name
+	}
+	return "/" + command.Name + " " + command.Args
+}
+
+func slashHelp(s *State, _ string) tea.Cmd {
+	lines := []string{"Commands, tab completes names and arguments:", ""}
+	for _, name := range slashNames() {
+		command := slashCommands[name]
+		lines = append(lines, "  "+slashUsage(command)+"\n      "+command.Summary)
+	}
+	lines = append(lines, "", "Start a message with // to send it with a single leading slash.")
+	s.Viewport.SetContent(strings.Join(lines, "\n"))
+	s.Viewport.GotoTop()
+	return nil
+}
+
+func modelChoices(s *State) []string {
+	choices := []string{"default"}
+	for _, m := range s.models {
+		choices = append(choices, m.Id)
+	}
+	return choices
+}
+
+func slashModel(s *State, args string) tea.Cmd {
+	switch args {
+	case "":
+		return s.openPicker()
+	case "default":
+		s.pickModel("")
+		return nil
+	}
+	if len(s.models) > 0 {
+		known := false
+		for _, m := range s.models {
+			known = known || m.Id == args
+		}
+		if !known {
+			s.notice = "Unknown model " + args + ", press tab to complete it or use /model to search"
+			return nil
+		}
+	}
+	s.pickModel(args)
+	return nil
+}
+
+func personaChoices(s *State) []string {
+	choices := []string{"none"}
+	for _, p := range s.Config.Personas {
+		choices = append(choices, p.Name)
+	}
+	return choices
+}
+
+func slashPersona(s *State, args string) tea.Cmd {
+	c := s.Current
+	switch args {
+	case "":
+		s.cyclePersona(c)
+	case "none":
+		c.Persona = ""
+		c.Updated = time.Now()
+	default:
+		if _, err := s.Config.FindPersona(args); err != nil {
+			s.notice = err.Error()
+			return nil
+		}
+		c.Persona = args
+		c.Updated = time.Now()
+	}
+	s.save()
+	if s.notice == "" {
+		s.notice = c.Title() + " now uses " + s.modelLabel()
+	}
+	return nil
+}
+
+func slashClear(s *State, _ string) tea.Cmd {
+	s.Current.Clear()
+	s.save()
+	s.refreshViewport()
+	if s.notice == "" {
+		s.notice = "Conversation cleared"
+	}
+	return nil
+}
+
+func slashNew(s *State, args string) tea.Cmd {
+	conv := s.Conversations.Add()
+	conv.Name = args
+	s.open(conv)
+	s.save()
+	if s.notice == "" {
+		s.notice = "Started " + conv.Title()
+	}
+	return nil
+}
+
+func slashExport(s *State, args string) tea.Cmd {
+	if args == "" {
+		s.exporting = true
+		s.notice = "Export conversation as (m)arkdown, (h)tml or (j)son, any other key cancels"
+		return nil
+	}
+	format, err := export.ParseFormat(args)
+	if err != nil {
+		s.notice = err.Error()
+		return nil
+	}
+	if name, err := s.Current.exportFile(format); err != nil {
+		s.notice = err.Error()
+	} else {
+		s.notice = "Exported to " + name
+	}
+	return nil
+}
+
+// slashAttach adds a url to the files or videos sent with every following prompt of the session
+func slashAttach(s *State, args string) tea.Cmd {
+	p := &s.Config.Prompt
+	switch {
+	case args == "clear":
+		p.FileUrls, p.YoutubeUrls = nil, nil
+		s.notice = "Attachments removed"
+	case args == "":
+		attached := append(append([]string{}, p.FileUrls...), p.YoutubeUrls...)
+		if len(attached) == 0 {
+			s.notice = "Nothing attached, use /attach url"
+		} else {
+			s.notice = "Attached: " + strings.Join(attached, ", ")
+		}
+	case !strings.HasPrefix(args, "http://") && !strings.HasPrefix(args, "https://"):
+		s.notice = "Attachments are urls, got " + args
+	case isYoutube(args):
+		p.YoutubeUrls = append(p.YoutubeUrls, args)
+		s.notice = "Video attached to the next prompts"
+	default:
+		p.FileUrls = append(p.FileUrls, args)
+		s.notice = "File attached to the next prompts"
+	}
+	return nil
+}
+
+func isYoutube(url string) bool {
+	for _, host := range []string{"youtube.com/", "www.youtube.com/", "m.youtube.com/", "youtu.be/"} {
+		if strings.HasPrefix(url, "https://"+host) || strings.HasPrefix(url, "http://"+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// slashRetry drops the answers to the last prompt and asks it again
+func slashRetry(s *State, _ string) tea.Cmd {
+	c := s.Current
+	last := -1
+	for i, m := range c.Messages {
+		if m.Role == RoleUser {
+			last = i
+		}
+	}
+	if last == -1 {
+		s.notice = "No prompt to retry"
+		return nil
+	}
+	userMessage := c.Messages[last].Content
+	retried := c.Messages[last:]
+	c.Messages = c.Messages[:last]
+	if err := s.checkBudget(c, userMessage); err != nil {
+		c.Messages = append(c.Messages, retried...)
+		s.notice = err.Error() + ", /retry again to send it anyway"
+		return nil
+	}
+	return s.send(c, userMessage)
+}
+
+func slashCost(s *State, _ string) tea.Cmd {
+	var coins float64
+	answers := 0
+	for _, m := range s.Current.Messages {
+		if m.Role == RoleAssistant && !m.Error {
+			coins += m.Coins
+			answers++
+		}
+	}
+	s.notice = s.Current.Title() + ": " + strconv.FormatFloat(coins, 'f', 2, 64) + " coins over " + strconv.Itoa(answers) +
+		" answers, session: " + strconv.FormatFloat(s.CoinUsage, 'f', 2, 64) + " coins"
+	return nil
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func slashState(t *testing.T) *State {
+	t.Setenv("HOME", t.TempDir())
+	s := &State{Conversations: &Conversations{}, Textarea: textarea.New()}
+	s.Current = s.Conversations.Slot(0)
+	s.Config.Prompt = prompt.Prompt{Model: []string{"openai/gpt-4.1-mini"}}
+	s.Config.Personas = []cmd.Persona{{Name: "reviewer"}, {Name: "translator"}}
+	s.models = []cmd.Models{{Id: "openai/gpt-4.1-mini"}, {Id: "openai/gpt-4.1"}, {Id: "anthropic/claude-sonnet-4"}}
+	return s
+}
+
+func TestCompleteSlash(t *testing.T) {
+	s := slashState(t)
+	for _, test := range []struct{ typed, completed string }{
+		{"/mo", "/model "},
+		{"/model anth", "/model anthropic/claude-sonnet-4"},
+		{"/model openai/gpt", "/model openai/gpt-4.1"},
+		{"/persona tr", "/persona translator"},
+		{"/c", "/c"},
+		{"/unknown x", "/unknown x"},
+	} {
+		s.Textarea.SetValue(test.typed)
+		s.completeSlash()
+		if got := s.Textarea.Value(); got != test.completed {
+			t.Errorf("Expected %q to complete to %q, got %q", test.typed, test.completed, got)
+		}
+	}
+
+	s.Textarea.SetValue("/c")
+	if hint := s.slashHint(); !strings.Contains(hint, "/clear") || !strings.Contains(hint, "/cost") {
+		t.Errorf("Expected the hint to list /clear and /cost, got %q", hint)
+	}
+	s.Textarea.SetValue("hello")
+	if hint := s.slashHint(); hint != "" {
+		t.Errorf("Expected no hint for a message, got %q", hint)
+	}
+}
+
+func TestRunSlash(t *testing.T) {
+	s := slashState(t)
+	c := s.Current
+
+	s.runSlash("model anthropic/claude-sonnet-4")
+	if c.Model != "anthropic/claude-sonnet-4" {
+		t.Errorf("Expected the conversation bound to claude, got %q", c.Model)
+	}
+	s.runSlash("model gpt-5")
+	if c.Model != "anthropic/claude-sonnet-4" || !strings.Contains(s.notice, "Unknown model") {
+		t.Errorf("Expected an unknown model to be refused, got %q", s.notice)
+	}
+	s.runSlash("persona translator")
+	if c.Persona != "translator" {
+		t.Errorf("Expected the translator persona, got %q", c.Persona)
+	}
+	s.runSlash("persona none")
+	if c.Persona != "" {
+		t.Errorf("Expected no persona, got %q", c.Persona)
+	}
+	s.runSlash("attach https://youtu.be/abc")
+	s.runSlash("attach https://example.com/report.pdf")
+	if len(s.Config.Prompt.YoutubeUrls) != 1 || len(s.Config.Prompt.FileUrls) != 1 {
+		t.Errorf("Expected a video and a file, got %v and %v", s.Config.Prompt.YoutubeUrls, s.Config.Prompt.FileUrls)
+	}
+	s.runSlash("nope")
+	if !strings.Contains(s.notice, "Unknown command /nope") {
+		t.Errorf("Expected an unknown command notice, got %q", s.notice)
+	}
+
+	c.Messages = Messages{
+		{Role: RoleUser, Content: "first"},
+		{Role: RoleAssistant, Content: "answer"},
+		{Role: RoleUser, Content: "second"},
+		{Role: RoleAssistant, Content: "bad answer", Coins: 2},
+	}
+	s.runSlash("cost")
+	if !strings.Contains(s.notice, "2.00 coins over 2 answers") {
+		t.Errorf("Expected the conversation's cost, got %q", s.notice)
+	}
+	if s.runSlash("retry") == nil {
+		t.Fatal("Expected retry to send the prompt again")
+	}
+	if len(c.Messages) != 3 || c.Messages[2].Content != "second" {
+		t.Errorf("Expected the last answer replaced by the asked prompt, got %+v", c.Messages)
+	}
+}
+
+func TestSlashLine(t *testing.T) {
+	if line, ok := slashLine(" /model x "); !ok || line != "model x" {
+		t.Errorf("Expected a command, got %q %v", line, ok)
+	}
+	if _, ok := slashLine("//etc is a path"); ok {
+		t.Error("Expected a doubled slash to be a message")
+	}
+}
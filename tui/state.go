@@ -9,11 +9,38 @@ import (
 
 type State struct {
 	Viewport      viewport.Model
-	ConvSelection int
-	Conversations Conversations
-	Textarea      textarea.Model
-	SenderStyle   lipgloss.Style
-	Err           error
-	Config        cmd.ConfigFile
-	CoinUsage     float64
+	Conversations *Conversations
+	// Current is the conversation being shown
+	Current     *Conversation
+	Textarea    textarea.Model
+	SenderStyle lipgloss.Style
+	Err         error
+	Config      cmd.ConfigFile
+	CoinUsage   float64
+	// RawView shows answers as plain text instead of rendered markdown
+	RawView bool
+	// Selecting moves a cursor between messages and code blocks to copy them
+	Selecting bool
+	selection int
+	// browser lists, opens and renames saved conversations
+	browser browser
+	// search finds messages across every conversation
+	search searchOverlay
+	// picker chooses the model of the current conversation
+	picker modelPicker
+	// models is the chat model catalog, modelsErr why it couldn't be loaded
+	models    []cmd.Models
+	modelsErr error
+	// spentToday is the coins spent today by every window, as of the last answer
+	spentToday float64
+	// overBudget is the prompt to send anyway once it was held back for going over a budget
+	overBudget string
+	// balance is the coins left on the account, once balanceKnown. balanceWarned is set while it is low.
+	balance       float64
+	balanceKnown  bool
+	balanceWarned bool
+	// exporting waits for the key choosing the export format
+	exporting bool
+	// notice replaces the status line until the next key press
+	notice string
 }
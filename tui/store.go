@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/statefile"
+)
+
+// Conversation store backends
+const (
+	StoreJSON   = "json"
+	StoreSQLite = "sqlite"
+)
+
+// Store loads and saves conversations
+type Store interface {
+	Load(c *Conversations) error
+	Save(c *Conversations) error
+	// RecordUsage keeps a copy of answers written to the usage ledger, for stores that have their own record
+	RecordUsage(entries []cmd.UsageEntry) error
+	Close() error
+}
+
+// OpenStore opens the backend, an empty backend is the json file
+func OpenStore(backend string) (Store, error) {
+	switch backend {
+	case "", StoreJSON:
+		return &jsonStore{}, nil
+	case StoreSQLite:
+		return openSQLiteStore()
+	}
+	return nil, fmt.Errorf("unknown conversation store %q, expected json or sqlite", backend)
+}
+
+func getConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "AppData", "Roaming", "straico-cli"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "straico-cli"), nil
+	default: // linux and others
+		return filepath.Join(home, ".config", "straico-cli"), nil
+	}
+}
+
+// ConflictError reports conversations changed here and in another window since they were loaded.
+// The save still happened, the other window's version is kept as a copy.
+type ConflictError struct {
+	Titles []string
+}
+
+func (e *ConflictError) Error() string {
+	return "changed in another window, their version was saved as a copy: " + strings.Join(e.Titles, ", ")
+}
+
+// jsonStore keeps every conversation in a single json file
+type jsonStore struct {
+	// loaded is when each conversation was last updated, as read from or written to the file
+	loaded map[string]time.Time
+}
+
+func (s *jsonStore) path() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Ensure config directory exists
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating config directory: %w", err)
+	}
+	return filepath.Join(configDir, saveFile), nil
+}
+
+func (s *jsonStore) Load(c *Conversations) error {
+	configPath, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Return nil if file doesn't exist
+		}
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	s.remember(c)
+
+	return nil
+}
+
+// Save merges changes made by other windows since the file was loaded, then replaces it
+func (s *jsonStore) Save(c *Conversations) error {
+	configPath, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := statefile.Lock(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var conflicts []string
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	if err == nil {
+		var disk Conversations
+		// A damaged file is replaced, the previous version is still in the backups
+		if json.Unmarshal(data, &disk) == nil {
+			conflicts = s.merge(c, &disk)
+		}
+	}
+
+	encodedConfig, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing config file: %w", err)
+	}
+
+	if err := statefile.Write(configPath, encodedConfig, 0600); err != nil {
+		return fmt.Errorf("unable to write to config file %w", err)
+	}
+	s.remember(c)
+
+	if len(conflicts) > 0 {
+		return &ConflictError{Titles: conflicts}
+	}
+	return nil
+}
+
+// merge brings in conversations started or changed in another window, and returns the titles
+// of those changed in both
+func (s *jsonStore) merge(c, disk *Conversations) []string {
+	var conflicts []string
+	for _, theirs := range disk.List {
+		known, wasLoaded := s.loaded[theirs.ID]
+		ours := c.Find(theirs.ID)
+		switch {
+		case ours == nil && !wasLoaded:
+			// Started in another window
+			c.List = append(c.List, theirs)
+			if slot := disk.SlotOf(theirs); slot != -1 && c.Slots[slot] == "" {
+				c.Slots[slot] = theirs.ID
+			}
+		case ours == nil, theirs.Updated.Equal(known):
+			// Deleted here, or not changed elsewhere
+		case ours.Updated.Equal(known):
+			// Only changed elsewhere
+			*ours = *theirs
+		case sameConversation(ours, theirs):
+			// Both windows hold the same conversation, there is nothing to keep a copy of
+		default:
+			conflicts = append(conflicts, ours.Title())
+			theirs.ID = newID()
+			theirs.Name = theirs.Title() + " (other window)"
+			c.List = append(c.List, theirs)
+		}
+	}
+	return conflicts
+}
+
+// sameConversation reports whether a and b would be saved the same, their time aside
+func sameConversation(a, b *Conversation) bool {
+	other := *b
+	other.Updated = a.Updated
+	ours, errOurs := json.Marshal(a)
+	theirs, errTheirs := json.Marshal(&other)
+	return errOurs == nil && errTheirs == nil && bytes.Equal(ours, theirs)
+}
+
+// remember marks the conversations as in sync with the file
+func (s *jsonStore) remember(c *Conversations) {
+	s.loaded = make(map[string]time.Time, len(c.List))
+	for _, conv := range c.List {
+		s.loaded[conv.ID] = conv.Updated
+	}
+}
+
+// RecordUsage does nothing, the ledger is the json store's only usage record
+func (s *jsonStore) RecordUsage(entries []cmd.UsageEntry) error {
+	return nil
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
@@ -0,0 +1,332 @@
+package tui
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	_ "modernc.org/sqlite"
+)
+
+const databaseFile = "conversations.db"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id             TEXT PRIMARY KEY,
+	name           TEXT NOT NULL DEFAULT '',
+	persona        TEXT NOT NULL DEFAULT '',
+	updated        TEXT NOT NULL,
+	prompt_history TEXT NOT NULL DEFAULT '[]',
+	slot           INTEGER,
+	model          TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS messages (
+	conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+	position        INTEGER NOT NULL,
+	role            TEXT NOT NULL,
+	content         TEXT NOT NULL,
+	model           TEXT NOT NULL DEFAULT '',
+	timestamp       TEXT NOT NULL,
+	coins           REAL NOT NULL DEFAULT 0,
+	tokens          INTEGER NOT NULL DEFAULT 0,
+	words           INTEGER NOT NULL DEFAULT 0,
+	finish_reason   TEXT NOT NULL DEFAULT '',
+	error           INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (conversation_id, position)
+);
+CREATE TABLE IF NOT EXISTS usage (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	time            TEXT NOT NULL,
+	conversation_id TEXT NOT NULL,
+	model           TEXT NOT NULL,
+	coins           REAL NOT NULL,
+	tokens          INTEGER NOT NULL,
+	words           INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// sqliteStore keeps conversations in a database, so a save only writes what changed
+type sqliteStore struct {
+	db *sql.DB
+	// saved is what the database holds for each conversation's messages
+	saved map[string]savedMessages
+}
+
+type savedMessages struct {
+	count int
+	last  Message
+}
+
+func openSQLiteStore() (*sqliteStore, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	path := filepath.Join(configDir, databaseFile)
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("error opening conversation database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating conversation database: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error upgrading conversation database: %w", err)
+	}
+	// Conversations are private, like the json file
+	if err := os.Chmod(path, 0600); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error securing conversation database: %w", err)
+	}
+	return &sqliteStore{db: db, saved: make(map[string]savedMessages)}, nil
+}
+
+// migrate adds the columns newer versions need to a database created by an older one
+func migrate(db *sql.DB) error {
+	var found int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('conversations') WHERE name = 'model'`).Scan(&found)
+	if err != nil || found > 0 {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE conversations ADD COLUMN model TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// Load reads every conversation, importing the json file the first time
+func (s *sqliteStore) Load(c *Conversations) error {
+	var imported string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'json_imported'`).Scan(&imported)
+	if err == sql.ErrNoRows {
+		return s.importJSON(c)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading conversation database: %w", err)
+	}
+
+	rows, err := s.db.Query(`SELECT id, name, persona, model, updated, prompt_history, slot FROM conversations`)
+	if err != nil {
+		return fmt.Errorf("error reading conversations: %w", err)
+	}
+	defer rows.Close()
+
+	loaded := Conversations{store: s}
+	byID := make(map[string]*Conversation)
+	for rows.Next() {
+		conv := &Conversation{pSelection: -1}
+		var updated, history string
+		var slot sql.NullInt64
+		if err := rows.Scan(&conv.ID, &conv.Name, &conv.Persona, &conv.Model, &updated, &history, &slot); err != nil {
+			return fmt.Errorf("error reading conversations: %w", err)
+		}
+		conv.Updated, _ = time.Parse(time.RFC3339Nano, updated)
+		if err := json.Unmarshal([]byte(history), &conv.PromptHistory); err != nil {
+			return fmt.Errorf("error reading prompt history: %w", err)
+		}
+		if slot.Valid && slot.Int64 >= 0 && slot.Int64 < SlotCount {
+			loaded.Slots[slot.Int64] = conv.ID
+		}
+		conv.Messages = Messages{}
+		loaded.List = append(loaded.List, conv)
+		byID[conv.ID] = conv
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading conversations: %w", err)
+	}
+
+	messages, err := s.db.Query(`SELECT conversation_id, role, content, model, timestamp, coins, tokens, words, finish_reason, error
+		FROM messages ORDER BY conversation_id, position`)
+	if err != nil {
+		return fmt.Errorf("error reading messages: %w", err)
+	}
+	defer messages.Close()
+	for messages.Next() {
+		var id, timestamp string
+		var m Message
+		if err := messages.Scan(&id, &m.Role, &m.Content, &m.Model, &timestamp, &m.Coins, &m.Tokens, &m.Words, &m.FinishReason, &m.Error); err != nil {
+			return fmt.Errorf("error reading messages: %w", err)
+		}
+		m.Timestamp, _ = time.Parse(time.RFC3339Nano, timestamp)
+		if conv, ok := byID[id]; ok {
+			conv.Messages = append(conv.Messages, m)
+		}
+	}
+	if err := messages.Err(); err != nil {
+		return fmt.Errorf("error reading messages: %w", err)
+	}
+
+	for _, conv := range loaded.List {
+		s.saved[conv.ID] = savedFrom(conv.Messages)
+	}
+	*c = loaded
+	return nil
+}
+
+// importJSON copies the conversations of the json file, which is left in place
+func (s *sqliteStore) importJSON(c *Conversations) error {
+	if err := (&jsonStore{}).Load(c); err != nil {
+		return err
+	}
+	c.store = s
+	if err := s.Save(c); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO meta (key, value) VALUES ('json_imported', ?)`, time.Now().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("error writing conversation database: %w", err)
+	}
+	return nil
+}
+
+// Save writes the conversations in a single transaction, messages already saved are not written again.
+// Conversations other windows started are left alone.
+func (s *sqliteStore) Save(c *Conversations) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error writing conversation database: %w", err)
+	}
+	defer tx.Rollback()
+
+	var conflicts []string
+	saved := make(map[string]savedMessages, len(c.List))
+	for _, conv := range c.List {
+		conflict, err := s.saveConversation(tx, c, conv)
+		if err != nil {
+			return fmt.Errorf("error writing conversation database: %w", err)
+		}
+		if conflict {
+			conflicts = append(conflicts, conv.Title())
+		}
+		saved[conv.ID] = savedFrom(conv.Messages)
+	}
+
+	// Conversations deleted here since the last save
+	for id := range s.saved {
+		if _, ok := saved[id]; ok {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM conversations WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("error writing conversation database: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error writing conversation database: %w", err)
+	}
+	s.saved = saved
+	if len(conflicts) > 0 {
+		return &ConflictError{Titles: conflicts}
+	}
+	return nil
+}
+
+// saveConversation writes conv, reporting whether another window had changed its messages
+func (s *sqliteStore) saveConversation(tx *sql.Tx, c *Conversations, conv *Conversation) (bool, error) {
+	previous, known := s.saved[conv.ID]
+	conflict := false
+	if known {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM messages WHERE conversation_id = ?`, conv.ID).Scan(&count); err != nil {
+			return false, err
+		}
+		if count != previous.count {
+			// Keep the other window's version as a copy, then write ours in full
+			conflict = true
+			copyID := newID()
+			_, err := tx.Exec(`INSERT INTO conversations (id, name, persona, model, updated, prompt_history)
+				SELECT ?, ?, persona, model, updated, prompt_history FROM conversations WHERE id = ?`,
+				copyID, conv.Title()+" (other window)", conv.ID)
+			if err != nil {
+				return false, err
+			}
+			if _, err := tx.Exec(`UPDATE messages SET conversation_id = ? WHERE conversation_id = ?`, copyID, conv.ID); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	history, err := json.Marshal(conv.PromptHistory)
+	if err != nil {
+		return false, err
+	}
+	var slot any
+	if n := c.SlotOf(conv); n != -1 {
+		slot = n
+	}
+	_, err = tx.Exec(`INSERT INTO conversations (id, name, persona, model, updated, prompt_history, slot) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, persona = excluded.persona, model = excluded.model,
+		updated = excluded.updated, prompt_history = excluded.prompt_history, slot = excluded.slot`,
+		conv.ID, conv.Name, conv.Persona, conv.Model, conv.Updated.Format(time.RFC3339Nano), string(history), slot)
+	if err != nil {
+		return false, err
+	}
+
+	// Messages are only appended, unless the conversation was cleared
+	from := previous.count
+	appended := !conflict && len(conv.Messages) >= previous.count &&
+		(previous.count == 0 || conv.Messages[previous.count-1] == previous.last)
+	if !appended {
+		if _, err := tx.Exec(`DELETE FROM messages WHERE conversation_id = ?`, conv.ID); err != nil {
+			return false, err
+		}
+		from = 0
+	}
+
+	for i := from; i < len(conv.Messages); i++ {
+		m := conv.Messages[i]
+		_, err := tx.Exec(`INSERT INTO messages (conversation_id, position, role, content, model, timestamp, coins, tokens, words, finish_reason, error)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			conv.ID, i, m.Role, m.Content, m.Model, m.Timestamp.Format(time.RFC3339Nano), m.Coins, m.Tokens, m.Words, m.FinishReason, m.Error)
+		if err != nil {
+			return false, err
+		}
+	}
+	return conflict, nil
+}
+
+// RecordUsage adds the answers to the usage table, with the same figures as the ledger
+func (s *sqliteStore) RecordUsage(entries []cmd.UsageEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error recording usage: %w", err)
+	}
+	defer tx.Rollback()
+	for _, e := range entries {
+		_, err := tx.Exec(`INSERT INTO usage (time, conversation_id, model, coins, tokens, words) VALUES (?, ?, ?, ?, ?, ?)`,
+			e.Time.Format(time.RFC3339Nano), e.Conversation, e.Model, e.Price.Total, e.Tokens, int64(e.Words.Total))
+		if err != nil {
+			return fmt.Errorf("error recording usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error recording usage: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func savedFrom(messages Messages) savedMessages {
+	if len(messages) == 0 {
+		return savedMessages{}
+	}
+	return savedMessages{count: len(messages), last: messages[len(messages)-1]}
+}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/cmd"
+	"github.com/tyler71/straico-cli/m/v0/prompt"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// The json file is imported the first time the database is opened
+	legacy := &Conversations{}
+	conv := legacy.Slot(2)
+	conv.Name = "imported"
+	conv.Model = "anthropic/claude-sonnet-4"
+	conv.PromptHistory = []string{"Hello"}
+	conv.Messages = Messages{
+		{Role: RoleUser, Content: "Hello", Timestamp: time.Now().Add(-time.Minute)},
+		{Role: RoleAssistant, Content: "Hi", Model: "test-model", Coins: 0.5, Timestamp: time.Now().Add(-time.Minute)},
+	}
+	if err := legacy.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save json conversations: %v", err)
+	}
+
+	conversations, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite store: %v", err)
+	}
+	imported := conversations.Find("imported")
+	if imported == nil || conversations.SlotOf(imported) != 2 || len(imported.Messages) != 2 {
+		t.Fatalf("Expected the json conversation in slot 3, got %+v", conversations)
+	}
+
+	imported.Messages = append(imported.Messages, Message{Role: RoleAssistant, Content: "More", Coins: 0.25, Timestamp: time.Now()})
+	other := conversations.Add()
+	other.Messages = Messages{{Role: RoleUser, Content: "Other"}}
+	if err := conversations.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	conversations.Delete(other)
+	imported.Clear()
+	imported.Messages = append(imported.Messages, Message{Role: RoleUser, Content: "Again", Timestamp: time.Now()})
+	if err := conversations.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	conversations.Close()
+
+	// Changing the json file no longer affects the database
+	if err := os.Remove(filepath.Join(home, ".config", "straico-cli", saveFile)); err != nil {
+		t.Fatalf("Failed to remove json file: %v", err)
+	}
+	reopened, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to reopen sqlite store: %v", err)
+	}
+	defer reopened.Close()
+
+	if len(reopened.List) != 1 {
+		t.Fatalf("Expected the deleted conversation to be gone, got %d conversations", len(reopened.List))
+	}
+	conv = reopened.Slot(2)
+	if conv.Name != "imported" || len(conv.PromptHistory) != 0 || len(conv.Messages) != 1 || conv.Messages[0].Content != "Again" {
+		t.Errorf("Expected the cleared conversation, got %+v", conv)
+	}
+	if conv.Model != "anthropic/claude-sonnet-4" {
+		t.Errorf("Expected the conversation's model to be kept, got %q", conv.Model)
+	}
+
+	// Answers are copied from the ledger entries, saving the conversations again adds nothing
+	entries := cmd.UsageEntries(prompt.Result{Models: []prompt.ModelResult{
+		{Model: "test-model", Price: prompt.OverallPrice{Total: 0.5}, Words: prompt.OverallPrice{Total: 40}, Usage: prompt.Usage{TotalTokens: 60}},
+		{Model: "other-model", Price: prompt.OverallPrice{Total: 0.25}},
+	}}, cmd.SourceChat, conv.ID, "")
+	if err := reopened.RecordUsage(entries); err != nil {
+		t.Fatalf("Failed to record usage: %v", err)
+	}
+	if err := reopened.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	var coins float64
+	var count, tokens int
+	store := reopened.store.(*sqliteStore)
+	if err := store.db.QueryRow(`SELECT COUNT(*), SUM(coins), SUM(tokens) FROM usage WHERE conversation_id = ?`, conv.ID).Scan(&count, &coins, &tokens); err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	if count != 2 || coins != 0.75 || tokens != 60 {
+		t.Errorf("Expected 2 answers costing 0.75 coins for 60 tokens, got %d costing %f for %d", count, coins, tokens)
+	}
+}
+
+func TestSQLiteStoreMigrate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A database from before conversations had their own model, with answers already recorded
+	dir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, databaseFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE conversations (id TEXT PRIMARY KEY, name TEXT NOT NULL DEFAULT '', persona TEXT NOT NULL DEFAULT '',
+		updated TEXT NOT NULL, prompt_history TEXT NOT NULL DEFAULT '[]', slot INTEGER);
+		INSERT INTO conversations (id, name, updated, slot) VALUES ('old', 'old', '2025-01-01T00:00:00Z', 0);
+		CREATE TABLE usage (id INTEGER PRIMARY KEY AUTOINCREMENT, time TEXT NOT NULL, conversation_id TEXT NOT NULL,
+			model TEXT NOT NULL, coins REAL NOT NULL, tokens INTEGER NOT NULL, words INTEGER NOT NULL);
+		INSERT INTO usage (time, conversation_id, model, coins, tokens, words) VALUES ('2025-01-01T00:00:00Z', 'old', 'test-model', 0.5, 60, 40);
+		CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+		INSERT INTO meta (key, value) VALUES ('json_imported', '2025-01-01T00:00:00Z')`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create the old database: %v", err)
+	}
+
+	conversations, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to open the old database: %v", err)
+	}
+	defer func() { conversations.Close() }()
+	old := conversations.Find("old")
+	if old == nil {
+		t.Fatal("Expected the old conversation to be loaded")
+	}
+	old.Model = "openai/gpt-4.1"
+	old.Updated = time.Now()
+	if err := conversations.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	// Opening the database again must not lose the recorded answers
+	conversations.Close()
+	conversations, err = NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to reopen the database: %v", err)
+	}
+	var coins float64
+	store := conversations.store.(*sqliteStore)
+	if err := store.db.QueryRow(`SELECT SUM(coins) FROM usage`).Scan(&coins); err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	if coins != 0.5 {
+		t.Errorf("Expected the recorded 0.5 coins to be kept, got %f", coins)
+	}
+}
+
+func TestOpenStoreUnknown(t *testing.T) {
+	if _, err := OpenStore("yaml"); err == nil {
+		t.Error("Expected error for unknown store")
+	}
+}
+
+func TestSQLiteStoreConflict(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	a, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite store: %v", err)
+	}
+	defer a.Close()
+	conv := a.Slot(0)
+	conv.Messages = Messages{{Role: RoleUser, Content: "shared"}}
+	if err := a.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	b, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite store: %v", err)
+	}
+	defer b.Close()
+	b.Slot(0).Messages = append(b.Slot(0).Messages, Message{Role: RoleAssistant, Content: "answer in b"})
+	b.Add().Name = "from b"
+	if err := b.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save b: %v", err)
+	}
+
+	conv.Messages = append(conv.Messages, Message{Role: RoleAssistant, Content: "answer in a"})
+	var conflict *ConflictError
+	if err := a.SaveConversations(); !errors.As(err, &conflict) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+
+	reopened, err := NewConversations(StoreSQLite)
+	if err != nil {
+		t.Fatalf("Failed to reopen sqlite store: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Find("from b") == nil {
+		t.Error("Expected the conversation started in b to be kept")
+	}
+	if got := reopened.Slot(0).Messages; got[len(got)-1].Content != "answer in a" {
+		t.Errorf("Expected a's version in the slot, got %+v", got)
+	}
+	if copied := reopened.Find("shared (other window)"); copied == nil || copied.Messages[1].Content != "answer in b" {
+		t.Error("Expected b's version to be kept as a copy")
+	}
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tyler71/straico-cli/m/v0/statefile"
+)
+
+func TestJSONStoreMerge(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	first := &Conversations{}
+	shared := first.Slot(0)
+	shared.Messages = Messages{{Role: RoleUser, Content: "shared"}}
+	untouched := first.Add()
+	if err := first.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	// Two windows open the same file
+	a, err := NewConversations(StoreJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	b, err := NewConversations(StoreJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	started := a.Add()
+	started.Name = "from a"
+	changed := a.Find(untouched.ID)
+	changed.Name = "renamed in a"
+	changed.Updated = time.Now()
+	a.Find(shared.ID).Messages = append(a.Find(shared.ID).Messages, Message{Role: RoleAssistant, Content: "answer in a"})
+	a.Find(shared.ID).Updated = time.Now()
+	if err := a.SaveConversations(); err != nil {
+		t.Fatalf("Failed to save a: %v", err)
+	}
+
+	b.Find(shared.ID).Messages = append(b.Find(shared.ID).Messages, Message{Role: RoleAssistant, Content: "answer in b"})
+	b.Find(shared.ID).Updated = time.Now()
+	var conflict *ConflictError
+	if err := b.SaveConversations(); !errors.As(err, &conflict) || len(conflict.Titles) != 1 {
+		t.Fatalf("Expected a conflict for the shared conversation, got %v", err)
+	}
+
+	if b.Find("from a") == nil {
+		t.Error("Expected the conversation started in a to be merged")
+	}
+	if b.Find(untouched.ID).Name != "renamed in a" {
+		t.Error("Expected the rename in a to be merged")
+	}
+	if got := b.Find(shared.ID).Messages; got[len(got)-1].Content != "answer in b" {
+		t.Errorf("Expected b to keep its own version, got %+v", got)
+	}
+	copied := b.Find("shared (other window)")
+	if copied == nil || copied.Messages[len(copied.Messages)-1].Content != "answer in a" {
+		t.Errorf("Expected a's version to be kept as a copy")
+	}
+
+	saved, err := NewConversations(StoreJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(saved.List) != 4 {
+		t.Errorf("Expected 4 conversations on disk, got %d", len(saved.List))
+	}
+	if _, err := os.Stat(statefile.BackupPath(filepath.Join(home, ".config", "straico-cli", saveFile), 1)); err != nil {
+		t.Errorf("Expected a backup of the previous version, got %v", err)
+	}
+}
+
+func TestJSONStoreSaveMigrated(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Buffers saved by an older version, whose messages have no time
+	configDir := filepath.Join(home, ".config", "straico-cli")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	legacy := `[{"prompt_history": ["hi"], "messages": ["\u001b[35mYou: \u001b[0mhi"]}, {"prompt_history": [], "messages": []}]`
+	if err := os.WriteFile(filepath.Join(configDir, saveFile), []byte(legacy), 0600); err != nil {
+		t.Fatalf("Failed to write conversations: %v", err)
+	}
+
+	conversations, err := NewConversations(StoreJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	// The first answer after the migration is saved over the unchanged file
+	conv := conversations.Slot(0)
+	conv.Messages = append(conv.Messages, Message{Role: RoleAssistant, Content: "hello", Timestamp: time.Now()})
+	conv.Updated = time.Now()
+	if err := conversations.SaveConversations(); err != nil {
+		t.Fatalf("Expected the migrated buffers to save without a conflict, got %v", err)
+	}
+	if len(conversations.List) != 1 || conversations.Find("hi (other window)") != nil {
+		t.Errorf("Expected only the migrated conversation, got %d conversations", len(conversations.List))
+	}
+	if got := conversations.Slot(0).Messages; len(got) != 2 {
+		t.Errorf("Expected the answer to be kept, got %+v", got)
+	}
+}
+
+func TestJSONStoreMergeIdentical(t *testing.T) {
+	loaded := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ours := &Conversation{ID: "a", Name: "same", Updated: loaded.Add(time.Minute)}
+	theirs := &Conversation{ID: "a", Name: "same", Updated: loaded.Add(time.Hour)}
+	s := &jsonStore{loaded: map[string]time.Time{"a": loaded}}
+	c := &Conversations{List: []*Conversation{ours}}
+
+	if conflicts := s.merge(c, &Conversations{List: []*Conversation{theirs}}); len(conflicts) != 0 || len(c.List) != 1 {
+		t.Errorf("Expected no conflict when both windows hold the same conversation, got %v", conflicts)
+	}
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tyler71/straico-cli/m/v0/export"
+)
+
+// Transcript converts the conversation for export
+func (c Conversation) Transcript(title string) export.Transcript {
+	t := export.Transcript{
+		Title:    title,
+		Persona:  c.Persona,
+		Exported: time.Now(),
+		Entries:  make([]export.Entry, len(c.Messages)),
+	}
+	for i, m := range c.Messages {
+		t.Coins += m.Coins
+		t.Entries[i] = export.Entry{
+			Role:         m.Role,
+			Content:      m.Content,
+			Model:        m.Model,
+			Timestamp:    m.Timestamp,
+			Coins:        m.Coins,
+			Tokens:       m.Tokens,
+			FinishReason: m.FinishReason,
+			Error:        m.Error,
+		}
+	}
+	return t
+}
+
+// exportFile writes the conversation to a new file in the working directory and returns its name
+func (c *Conversation) exportFile(format string) (string, error) {
+	name := "straico-" + c.ID + "-" + time.Now().Format("20060102-150405") + "." + export.Extension(format)
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("unable to create export file: %w", err)
+	}
+	defer f.Close()
+
+	transcript := c.Transcript(c.Title())
+	if err := export.Write(f, format, transcript); err != nil {
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// updateExport handles the key choosing the export format for the selected buffer
+func (s *State) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s.exporting = false
+	formats := map[string]string{"m": export.FormatMarkdown, "h": export.FormatHTML, "j": export.FormatJSON}
+	format, ok := formats[msg.String()]
+	if !ok {
+		s.notice = "Export cancelled"
+	} else if name, err := s.Current.exportFile(format); err != nil {
+		s.notice = err.Error()
+	} else {
+		s.notice = "Exported to " + name
+	}
+	s.Textarea.Placeholder = s.notice
+	return s, nil
+}
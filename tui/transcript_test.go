@@ -0,0 +1,28 @@
+package tui
+
+import (
+	"testing"
+)
+
+func TestConversationTranscript(t *testing.T) {
+	c := Conversation{
+		Persona: "reviewer",
+		Messages: Messages{
+			{Role: RoleUser, Content: "Question"},
+			{Role: RoleAssistant, Content: "Answer A", Model: "model-a", Coins: 0.5},
+			{Role: RoleAssistant, Content: "Answer B", Model: "model-b", Coins: 0.25},
+		},
+	}
+
+	transcript := c.Transcript("Test")
+
+	if transcript.Title != "Test" || transcript.Persona != "reviewer" {
+		t.Errorf("Unexpected title or persona %q, %q", transcript.Title, transcript.Persona)
+	}
+	if transcript.Coins != 0.75 {
+		t.Errorf("Expected total coins 0.75, got %f", transcript.Coins)
+	}
+	if len(transcript.Entries) != 3 || transcript.Entries[2].Model != "model-b" {
+		t.Errorf("Unexpected entries %+v", transcript.Entries)
+	}
+}